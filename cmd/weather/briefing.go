@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// displayBriefing shows a forecast as a short paragraph for -briefing: the
+// current conditions, which way the highs are heading, how much rain is due,
+// how the wind turns and the best day ahead.
+func displayBriefing(out io.Writer, f *weather.Forecast, opts *displayOptions) {
+	var sentences []string
+
+	if w := f.Current; w != nil {
+		sentences = append(sentences, fmt.Sprintf("%s: %s, %s (feels like %s), wind %s%s%s.",
+			f.Location, w.Conditions, opts.units.formatTemp(w.Temperature), opts.units.formatTemp(w.FeelsLike),
+			opts.units.formatSpeed(w.WindSpeed), windDirection(w.WindSpeed, w.WindDirection, opts), beaufortForce(w.WindSpeed, opts)))
+	} else {
+		sentences = append(sentences, f.Location+".")
+	}
+
+	if days := len(f.DailyItems); days > 1 {
+		switch f.TempTrend() {
+		case weather.Warming:
+			sentences = append(sentences, fmt.Sprintf("Highs are warming over the next %d days.", days))
+		case weather.Cooling:
+			sentences = append(sentences, fmt.Sprintf("Highs are cooling over the next %d days.", days))
+		default:
+			sentences = append(sentences, fmt.Sprintf("Highs hold steady over the next %d days.", days))
+		}
+	}
+
+	if days := len(f.DailyItems); days > 0 {
+		switch rainy := f.RainyDays(weather.DefaultRainyChance); rainy {
+		case 0:
+			sentences = append(sentences, "No rain expected.")
+		case days:
+			sentences = append(sentences, "Rain likely every day.")
+		default:
+			streak := f.DryStreak(weather.DefaultRainyChance)
+			unit := "days"
+			if streak == 1 {
+				unit = "day"
+			}
+			sentences = append(sentences, fmt.Sprintf("Rain likely on %d of %d days; the longest dry spell is %d %s.",
+				rainy, days, streak, unit))
+		}
+	}
+
+	if winds := f.WindSummary(); winds != "" {
+		sentences = append(sentences, strings.ToUpper(winds[:1])+winds[1:]+".")
+	}
+
+	if day, ok := f.BestDay(); ok {
+		sentences = append(sentences, fmt.Sprintf("Best day: %s, %s with a high of %s.",
+			day.Date.Format("Monday"), day.Conditions, opts.units.formatTemp(day.High)))
+	}
+
+	fmt.Fprintln(out, strings.Join(sentences, " "))
+}
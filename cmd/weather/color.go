@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiRed    = "\033[31m"
+	ansiYellow = "\033[33m"
+)
+
+// colorEnabled decides whether to emit ANSI escapes. The -color flag accepts
+// always, never or auto; see https://no-color.org for NO_COLOR. Precedence:
+// -color=always > NO_COLOR > -color=never > auto-detect TTY.
+func colorEnabled(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never", "auto", "":
+	default:
+		return false, fmt.Errorf("invalid -color value: %s (want always, never or auto)", mode)
+	}
+
+	if _, set := os.LookupEnv("NO_COLOR"); set {
+		return false, nil
+	}
+	if mode == "never" {
+		return false, nil
+	}
+
+	return isTerminal(os.Stdout), nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize wraps s in the ANSI code, if opts.color is set.
+func (opts *displayOptions) colorize(code, s string) string {
+	if !opts.color {
+		return s
+	}
+	return code + s + ansiReset
+}
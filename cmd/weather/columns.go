@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// forecastColumns are the optional columns of the forecast table, chosen with
+// -show. A column that is shown is shown for every day, with "-" for days the
+// provider has no data for.
+type forecastColumns struct {
+	wind     bool
+	humidity bool
+	precip   bool
+}
+
+// defaultColumns are shown when -show isn't given.
+var defaultColumns = forecastColumns{wind: true, humidity: true}
+
+// parseColumns parses a comma-separated -show list such as "wind,precip". An
+// empty list shows only the conditions and temperatures.
+func parseColumns(list string) (forecastColumns, error) {
+	var c forecastColumns
+	for _, name := range strings.Split(list, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "wind":
+			c.wind = true
+		case "humidity":
+			c.humidity = true
+		case "precip":
+			c.precip = true
+		default:
+			return c, fmt.Errorf("unknown -show column %q (want wind, humidity or precip)", name)
+		}
+	}
+	return c, nil
+}
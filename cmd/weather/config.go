@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// The config file has one "key = value" setting per line; blank lines and
+// lines starting with '#' are ignored. For example:
+//
+//	temperature_unit = C
+//	wind_unit = kph
+//	statusbar_fields = temp,humidity,wind
+//	group.boston = Boston, MA; Cambridge, MA; Somerville, MA
+//	severity.snow = high
+//
+// A group.<name> setting names a group of locations, separated by
+// semicolons, for -group. A severity.<condition> setting overrides how severe
+// a condition is, from none, low, medium or high: high conditions in the
+// forecast get an advisory, and high and medium ones are colored.
+//
+// The connection pool every request shares, which -serve may want larger,
+// is tuned with max_idle_conns and max_idle_conns_per_host (0 is no limit),
+// idle_conn_timeout, a duration such as 90s, and keep_alives = false to make
+// a new connection for every request.
+//
+// A units setting sets several units at once, as the -si, -us and -ski flags
+// do: metric (or si), imperial (or us) or ski. Settings for single units
+// override it.
+//
+// A country setting is the country of a place given by name alone, on the
+// command line, in a locations file or in a group, so with country = FR,
+// "Paris" is "Paris, FR".
+//
+// A location or provider setting is used when the command line doesn't give
+// one. A [profile.<name>] line starts a profile, whose settings down to the
+// next profile override the ones above for -profile=<name>:
+//
+//	temperature_unit = F
+//
+//	[profile.home]
+//	location = 02108
+//
+//	[profile.work]
+//	location = London, GB
+//	temperature_unit = C
+//	provider = openweather
+func configPath() string {
+	return os.ExpandEnv("$HOME/.config/weather/config")
+}
+
+// loadConfig reads the settings from path. A missing file is not an error,
+// it just has no settings. Settings in a profile are keyed
+// "profile.<name>.<key>".
+func loadConfig(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening config file: %v", err)
+	}
+	defer f.Close()
+
+	settings := make(map[string]string)
+	prefix := ""
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if section, ok := configSection(line); ok {
+			name, ok := strings.CutPrefix(section, "profile.")
+			if !ok || name == "" {
+				return nil, fmt.Errorf("%s line %d: expected [profile.<name>]", path, lineNum)
+			}
+			prefix = "profile." + name + "."
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s line %d: expected key = value", path, lineNum)
+		}
+		settings[prefix+strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading config file: %v", err)
+	}
+
+	return settings, nil
+}
+
+// configSection returns the name of the section a "[name]" line starts.
+func configSection(line string) (string, bool) {
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return "", false
+	}
+	return strings.TrimSpace(line[1 : len(line)-1]), true
+}
+
+// selectProfile returns the settings outside any profile, overridden by
+// those in the named one if name isn't empty.
+func selectProfile(settings map[string]string, name string) (map[string]string, error) {
+	selected := make(map[string]string)
+	found := false
+	for key, value := range settings {
+		if !strings.HasPrefix(key, "profile.") {
+			selected[key] = value
+		}
+		if name != "" && strings.HasPrefix(key, "profile."+name+".") {
+			found = true
+		}
+	}
+	if name == "" {
+		return selected, nil
+	}
+	if !found {
+		return nil, fmt.Errorf("config: no profile %q", name)
+	}
+	for key, value := range settings {
+		if key, ok := strings.CutPrefix(key, "profile."+name+"."); ok {
+			selected[key] = value
+		}
+	}
+	return selected, nil
+}
+
+// applyConfig applies the settings from the config file to the display
+// options, rejecting unknown keys and invalid values.
+func applyConfig(settings map[string]string, opts *displayOptions) error {
+	// The preset goes first, so the single units can override it.
+	if name, ok := settings["units"]; ok {
+		preset, ok := unitPresets[unitPresetNames[strings.ToLower(name)]]
+		if !ok {
+			return fmt.Errorf("config: invalid units: %s (want metric, imperial or ski)", name)
+		}
+		for key, value := range preset {
+			if err := opts.units.set(key, value); err != nil {
+				return fmt.Errorf("config: %v", err)
+			}
+		}
+	}
+	for key, value := range settings {
+		if key == "location" || key == "units" {
+			// Defaults for the command line, which main fills in, or
+			// the preset applied above.
+			continue
+		}
+		if key == "provider" {
+			for _, name := range strings.Split(value, ",") {
+				if _, err := lookupProvider(strings.TrimSpace(name)); err != nil {
+					return fmt.Errorf("config: %v", err)
+				}
+			}
+			continue
+		}
+		if key == "country" {
+			if err := checkCountry(value); err != nil {
+				return fmt.Errorf("config: %v", err)
+			}
+			continue
+		}
+		if slices.Contains(transportKeys, key) {
+			// For the connection pool, which transportSettings reads.
+			continue
+		}
+		if _, ok := validUnits[key]; ok {
+			if err := opts.units.set(key, value); err != nil {
+				return fmt.Errorf("config: %v", err)
+			}
+			continue
+		}
+		if key == "statusbar_fields" {
+			fields, err := parseStatusbarFields(value)
+			if err != nil {
+				return fmt.Errorf("config: %v", err)
+			}
+			opts.statusbarFields = fields
+			continue
+		}
+		if name, ok := strings.CutPrefix(key, "severity."); ok {
+			c, ok := weather.ParseCondition(name)
+			if !ok {
+				return fmt.Errorf("config: %s: unknown condition %q", key, name)
+			}
+			var sev weather.Severity
+			if err := sev.UnmarshalText([]byte(value)); err != nil {
+				return fmt.Errorf("config: %s: %v (want none, low, medium or high)", key, err)
+			}
+			if opts.severities == nil {
+				opts.severities = make(weather.ConditionSeverities)
+			}
+			opts.severities[c] = sev
+			continue
+		}
+		if name, ok := strings.CutPrefix(key, "group."); ok && name != "" {
+			locations, err := parseGroup(value)
+			if err != nil {
+				return fmt.Errorf("config: %s: %v", key, err)
+			}
+			if opts.groups == nil {
+				opts.groups = make(map[string][]string)
+			}
+			opts.groups[name] = locations
+			continue
+		}
+		return fmt.Errorf("config: unknown setting: %s", key)
+	}
+	return nil
+}
+
+// checkCountry returns an error if a country setting is empty or would be
+// taken for a US state after a place name, as "CA" would.
+func checkCountry(country string) error {
+	q, err := weather.ParseLocation("Springfield, " + country)
+	if err != nil || strings.TrimSpace(country) == "" || strings.Contains(country, ",") {
+		return fmt.Errorf("invalid country: %q", country)
+	}
+	if q.Kind != weather.LocationCityCountry {
+		return fmt.Errorf("country %s is also a US state's abbreviation; give the country's name instead", country)
+	}
+	return nil
+}
+
+// inCountry returns location with country after it if it's a place name
+// alone, so "Paris" in country FR is "Paris, FR". Other locations, and all
+// of them if country is empty, are returned as they are.
+func inCountry(location, country string) string {
+	q, err := weather.ParseLocation(location)
+	if err != nil || country == "" || q.Kind != weather.LocationName {
+		return location
+	}
+	return q.Input + ", " + country
+}
+
+// transportKeys are the config settings for the shared connection pool.
+var transportKeys = []string{"max_idle_conns", "max_idle_conns_per_host", "idle_conn_timeout", "keep_alives"}
+
+// transportSettings returns weather.DefaultTransportSettings changed by any
+// transportKeys settings, and whether there were any.
+func transportSettings(settings map[string]string) (weather.TransportSettings, bool, error) {
+	s := weather.DefaultTransportSettings
+	changed := false
+	for _, key := range transportKeys {
+		value, ok := settings[key]
+		if !ok {
+			continue
+		}
+		changed = true
+		valid := true
+		switch key {
+		case "max_idle_conns":
+			s.MaxIdleConns, valid = parseCount(value)
+		case "max_idle_conns_per_host":
+			s.MaxIdleConnsPerHost, valid = parseCount(value)
+		case "idle_conn_timeout":
+			var err error
+			s.IdleConnTimeout, err = time.ParseDuration(value)
+			valid = err == nil && s.IdleConnTimeout >= 0
+		case "keep_alives":
+			keepAlives, err := strconv.ParseBool(value)
+			s.DisableKeepAlives = !keepAlives
+			valid = err == nil
+		}
+		if !valid {
+			return s, false, fmt.Errorf("config: invalid %s: %s", key, value)
+		}
+	}
+	return s, changed, nil
+}
+
+// parseCount parses a number of connections, which can't be negative.
+func parseCount(value string) (int, bool) {
+	n, err := strconv.Atoi(value)
+	return n, err == nil && n >= 0
+}
+
+// saveConfig sets the "key=value" settings in pairs in the config file at
+// path, for -save-config. They're validated as when the file is loaded, then
+// replace any existing lines for the same keys or are added at the end, so the
+// rest of the file, comments and profiles included, is kept as it was. Only
+// the settings outside any profile are saved.
+func saveConfig(path string, pairs []string) error {
+	if len(pairs) == 0 {
+		return fmt.Errorf("-save-config needs settings to save, e.g. temperature_unit=C")
+	}
+
+	var keys []string
+	settings := make(map[string]string)
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return fmt.Errorf("invalid setting %q: expected key=value", pair)
+		}
+		if _, seen := settings[key]; !seen {
+			keys = append(keys, key)
+		}
+		settings[key] = value
+	}
+	if err := applyConfig(settings, &displayOptions{units: defaultUnits}); err != nil {
+		return err
+	}
+	if _, _, err := transportSettings(settings); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading config file: %v", err)
+	}
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+
+	// New settings go before the first profile, so as not to land in it.
+	end := len(lines)
+	saved := make(map[string]bool)
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if _, ok := configSection(trimmed); ok {
+			end = i
+			for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+				end--
+			}
+			break
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		key, _, ok := strings.Cut(trimmed, "=")
+		key = strings.TrimSpace(key)
+		if value, set := settings[key]; ok && set {
+			lines[i] = key + " = " + value
+			saved[key] = true
+		}
+	}
+	var added []string
+	for _, key := range keys {
+		if !saved[key] {
+			added = append(added, key+" = "+settings[key])
+		}
+	}
+	lines = slices.Insert(lines, end, added...)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating config directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		return fmt.Errorf("error writing config file: %v", err)
+	}
+	return nil
+}
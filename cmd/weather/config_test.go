@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+func TestTransportSettings(t *testing.T) {
+	s, ok, err := transportSettings(map[string]string{"location": "02108"})
+	if err != nil || ok || s != weather.DefaultTransportSettings {
+		t.Errorf("without transport settings = %+v, %v, %v, want the defaults, unchanged", s, ok, err)
+	}
+
+	s, ok, err = transportSettings(map[string]string{
+		"max_idle_conns":          "200",
+		"max_idle_conns_per_host": "0",
+		"idle_conn_timeout":       "2m",
+		"keep_alives":             "false",
+	})
+	want := weather.TransportSettings{MaxIdleConns: 200, IdleConnTimeout: 2 * time.Minute, DisableKeepAlives: true}
+	if err != nil || !ok || s != want {
+		t.Errorf("transportSettings = %+v, %v, %v, want %+v", s, ok, err, want)
+	}
+
+	for key, value := range map[string]string{
+		"max_idle_conns":          "-1",
+		"max_idle_conns_per_host": "many",
+		"idle_conn_timeout":       "90",
+		"keep_alives":             "sometimes",
+	} {
+		if _, _, err := transportSettings(map[string]string{key: value}); err == nil {
+			t.Errorf("transportSettings(%s = %s) succeeded, want an error", key, value)
+		}
+	}
+}
+
+// The transport settings are known keys, which the display options ignore.
+func TestApplyConfigTransportKeys(t *testing.T) {
+	opts := &displayOptions{units: defaultUnits}
+	if err := applyConfig(map[string]string{"max_idle_conns": "50", "keep_alives": "true"}, opts); err != nil {
+		t.Errorf("applyConfig: %v", err)
+	}
+}
+
+// A units preset applies first, whatever the order of the settings, so single
+// units override it.
+func TestApplyConfigUnits(t *testing.T) {
+	opts := &displayOptions{units: defaultUnits}
+	if err := applyConfig(map[string]string{"wind_unit": "kph", "units": "Metric"}, opts); err != nil {
+		t.Fatalf("applyConfig: %v", err)
+	}
+	if opts.units.temperature != "C" || opts.units.pressure != "hPa" || opts.units.wind != "kph" {
+		t.Errorf("units = %+v, want metric with wind in kph", opts.units)
+	}
+
+	if err := applyConfig(map[string]string{"units": "nautical"}, &displayOptions{units: defaultUnits}); err == nil {
+		t.Error("applyConfig(units = nautical) succeeded, want an error")
+	}
+}
+
+func TestApplyConfigInvalid(t *testing.T) {
+	for key, value := range map[string]string{
+		"provider": "openmeteo,darksky",
+		"country":  "CA",
+		"color":    "true",
+	} {
+		if err := applyConfig(map[string]string{key: value}, &displayOptions{units: defaultUnits}); err == nil {
+			t.Errorf("applyConfig(%s = %s) succeeded, want an error", key, value)
+		}
+	}
+}
+
+func TestInCountry(t *testing.T) {
+	tests := []struct {
+		location, country, want string
+	}{
+		{"Paris", "FR", "Paris, FR"},
+		{" Paris ", "France", "Paris, France"},
+		{"Paris", "", "Paris"},
+		{"Paris, TX", "FR", "Paris, TX"},
+		{"London, GB", "FR", "London, GB"},
+		{"02108", "FR", "02108"},
+		{"48.85,2.35", "FR", "48.85,2.35"},
+	}
+	for _, tt := range tests {
+		if got := inCountry(tt.location, tt.country); got != tt.want {
+			t.Errorf("inCountry(%q, %q) = %q, want %q", tt.location, tt.country, got, tt.want)
+		}
+	}
+}
+
+func TestSaveConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	existing := "# defaults\nunits = imperial\n\n[profile.work]\nlocation = London, GB\n"
+	if err := os.WriteFile(path, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveConfig(path, []string{"provider=openmeteo", "units=metric", "country=US"}); err != nil {
+		t.Fatalf("saveConfig: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# defaults\nunits = metric\nprovider = openmeteo\ncountry = US\n\n[profile.work]\nlocation = London, GB\n"
+	if string(data) != want {
+		t.Errorf("config =\n%s\nwant\n%s", data, want)
+	}
+
+	for _, pair := range []string{"provider=darksky", "units=nautical", "shoe_size=9", "provider"} {
+		if err := saveConfig(path, []string{pair}); err == nil {
+			t.Errorf("saveConfig(%s) succeeded, want an error", pair)
+		}
+	}
+}
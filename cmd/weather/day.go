@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// weekdays are the day names that can be given, like "today", to show one day
+// of the forecast.
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// displayDay shows just one day of a forecast, for the "today" and
+// "tomorrow" keywords and the weekday names.
+func displayDay(out io.Writer, f *weather.Forecast, which string, opts *displayOptions) {
+	var day *weather.DailyForecast
+	var ok bool
+	switch which {
+	case "today":
+		day, ok = f.Today()
+	case "tomorrow":
+		day, ok = f.Tomorrow()
+	default:
+		day, ok = f.Day(weekdays[which])
+	}
+	title := cases.Title(language.English).String(which)
+	if !ok {
+		if _, isWeekday := weekdays[which]; isWeekday {
+			fmt.Fprintf(out, "No forecast for %s in %s: it's beyond the %d-day forecast\n", title, f.Location, len(f.DailyItems))
+			return
+		}
+		fmt.Fprintf(out, "No forecast for %s in %s\n", which, f.Location)
+		return
+	}
+
+	displayHeader(out, fmt.Sprintf("%s in %s (%s)%s:", title, f.Location, day.Date.Format("Mon 2006-01-02"), cachedNote(f.CachedAt)), opts)
+	fmt.Fprintf(out, "Conditions:  %s%s\n", conditionIcon(day.Condition, opts), titleConditions(day.Conditions, opts))
+	if day.Available("high") {
+		fmt.Fprintf(out, "High:        %s\n", opts.units.formatTemp(day.High))
+	}
+	if day.Available("low") {
+		fmt.Fprintf(out, "Low:         %s\n", opts.units.formatTemp(day.Low))
+	}
+	precip := fmt.Sprintf("%d%% chance", day.PrecipProbability)
+	if day.Precipitation > 0 {
+		precip += ", " + opts.units.formatPrecip(day.Precipitation)
+	}
+	fmt.Fprintf(out, "Precip:      %s\n", precip)
+	if day.Snowfall > 0 {
+		fmt.Fprintf(out, "Snow:        %s\n", opts.units.formatSnow(day.Snowfall))
+	}
+	if day.WindSpeed > 0 {
+		fmt.Fprintf(out, "Wind:        %s%s%s\n", opts.units.formatSpeed(day.WindSpeed), windDirection(day.WindSpeed, day.WindDirection, opts), beaufortForce(day.WindSpeed, opts))
+	}
+	if day.Humidity > 0 {
+		fmt.Fprintf(out, "Humidity:    %d%%\n", day.Humidity)
+	}
+}
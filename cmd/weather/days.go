@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// tableDays returns the days of f the forecast table shows: from tomorrow
+// when the current weather, which covers today, is shown above it, unless
+// -no-forecast-current-dedup.
+func tableDays(f *weather.Forecast, opts *displayOptions) []weather.DailyForecast {
+	if f.Current != nil && !opts.keepToday {
+		return f.FromTomorrow(time.Now())
+	}
+	return f.DailyItems
+}
+
+// selectDays returns a copy of f with only the -days days after the first
+// -offset of the days the table would show, or all of them after the offset
+// without -days. It's an error to ask for more than the provider returned.
+func selectDays(f *weather.Forecast, opts *displayOptions) (*weather.Forecast, error) {
+	days := tableDays(f, opts)
+	if opts.offset >= len(days) {
+		return nil, fmt.Errorf("-offset=%d skips all %d days of the forecast", opts.offset, len(days))
+	}
+	n := opts.days
+	if n == 0 {
+		n = len(days) - opts.offset
+	} else if opts.offset+n > len(days) {
+		return nil, fmt.Errorf("-offset=%d -days=%d needs %d days of forecast, but only %d are available",
+			opts.offset, n, opts.offset+n, len(days))
+	}
+	selected := *f
+	selected.DailyItems = days[opts.offset : opts.offset+n]
+	return &selected, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// displayResolution shows how a location was resolved for -explain, step by
+// step from the input to the place chosen.
+func displayResolution(res *weather.Resolution) {
+	fmt.Printf("Input:      %s\n", res.Input)
+	parsed := fmt.Sprintf("%s (%s)", res.Form, res.Name)
+	if res.State != "" {
+		parsed = fmt.Sprintf("%s (name %s, state %s)", res.Form, res.Name, res.State)
+	}
+	fmt.Printf("Parsed as:  %s\n", parsed)
+	if res.Query != "" {
+		fmt.Printf("Query:      %s\n", res.Query)
+		fmt.Printf("Candidates: %d\n", res.Candidates)
+	}
+
+	c := res.Chosen
+	place := c.Name
+	for _, part := range []string{c.State, c.Country} {
+		if part != "" {
+			place += ", " + part
+		}
+	}
+	fmt.Printf("Chosen:     %s (%.4f, %.4f)", place, c.Latitude, c.Longitude)
+	if c.Population > 0 {
+		fmt.Printf(", population %d", c.Population)
+	}
+	fmt.Println()
+	fmt.Printf("Why:        %s\n", res.Reason)
+}
+
+// forecastPointWarnKm is how far from coordinates asked for the forecast
+// point may be before a note says so.
+const forecastPointWarnKm = 5.0
+
+// displayForecastPoint notes how far, and with -bearing-and-distance which
+// way, the point the provider's data is for is from the coordinates asked
+// for. Nothing is shown for a location that isn't coordinates or a provider
+// that doesn't report the point.
+func displayForecastPoint(out io.Writer, location string, w *weather.CurrentWeather, opts *displayOptions) {
+	lat, lon, ok := weather.ParseCoordinates(location)
+	if !ok || w == nil || (w.Latitude == 0 && w.Longitude == 0) {
+		return
+	}
+
+	km := weather.Distance(lat, lon, w.Latitude, w.Longitude)
+	if opts.bearingAndDistance {
+		fmt.Fprintf(out, "\nForecast point: %s %s of %s (%.4f, %.4f)\n", opts.units.formatDistance(km),
+			weather.CompassDirection(weather.Bearing(lat, lon, w.Latitude, w.Longitude)), location,
+			w.Latitude, w.Longitude)
+		return
+	}
+	if km > forecastPointWarnKm {
+		fmt.Fprintln(out, opts.colorize(ansiYellow, fmt.Sprintf("\nNote: forecast point is %s away", opts.units.formatDistance(km))))
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// groupJSON is how -group is written with -format=json.
+type groupJSON struct {
+	Group     string                `json:"group"`
+	Summary   *weather.GroupSummary `json:"summary,omitempty"`
+	Locations []batchResultJSON     `json:"locations"`
+}
+
+// displayGroup shows the current temperature of each location in a -group,
+// then the lowest, highest and average across them. The error is from writing
+// the JSON.
+func displayGroup(name string, results []weather.BatchResult, opts *displayOptions, format string) error {
+	summary, ok := weather.SummarizeGroup(results)
+
+	if format == "json" {
+		out := groupJSON{Group: name, Locations: make([]batchResultJSON, 0, len(results))}
+		if ok {
+			out.Summary = &summary
+		}
+		for _, r := range results {
+			out.Locations = append(out.Locations, newBatchResultJSON(r))
+		}
+		return printJSON(out)
+	}
+
+	displayHeader(os.Stdout, fmt.Sprintf("Current Weather for %s:", name), opts)
+	width := 0
+	for _, r := range results {
+		if r.Err == nil {
+			width = max(width, displayWidth(r.Current.Location))
+		}
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Println(opts.colorize(ansiBold+ansiRed, fmt.Sprintf("[FAILED] %s: %v", r.Location, r.Err)))
+			continue
+		}
+		w := r.Current
+		temp := "-"
+		if w.Available("temperature") {
+			temp = opts.units.formatTemp(w.Temperature)
+		}
+		fmt.Printf("  %s  %8s  %s\n", padRight(w.Location, width), temp, w.Conditions)
+	}
+
+	if !ok {
+		return nil
+	}
+	fmt.Println()
+	fmt.Printf("Low:         %s (%s)\n", opts.units.formatTemp(summary.MinTemp), summary.Coldest)
+	fmt.Printf("High:        %s (%s)\n", opts.units.formatTemp(summary.MaxTemp), summary.Warmest)
+	fmt.Printf("Average:     %s", opts.units.formatTemp(summary.AvgTemp))
+	if summary.Reporting < summary.Locations {
+		fmt.Printf(" (%d of %d locations)", summary.Reporting, summary.Locations)
+	}
+	fmt.Println()
+	return nil
+}
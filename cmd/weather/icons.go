@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// iconSet is a table of glyphs for the conditions, for -icons and -icon-set.
+type iconSet struct {
+	icons map[weather.Condition]string
+	// wide is set for glyphs terminals show two columns wide, whatever
+	// their East Asian width says; the others are padded to two.
+	wide bool
+	// arrows is set if wind directions are shown as arrows, which fonts
+	// without emoji have too; ASCII has compass points instead.
+	arrows bool
+}
+
+// iconSets are the -icon-set tables by name. Conditions a table doesn't list
+// use its ConditionUnknown glyph.
+var iconSets = map[string]*iconSet{
+	"emoji": {
+		icons: map[weather.Condition]string{
+			weather.ConditionUnknown:      "❓",
+			weather.ConditionClear:        "☀️",
+			weather.ConditionPartlyCloudy: "⛅",
+			weather.ConditionCloudy:       "☁️",
+			weather.ConditionFog:          "🌫️",
+			weather.ConditionHaze:         "🌫️",
+			weather.ConditionDrizzle:      "🌦️",
+			weather.ConditionRain:         "🌧️",
+			weather.ConditionFreezingRain: "🧊",
+			weather.ConditionSleet:        "🌨️",
+			weather.ConditionSnow:         "❄️",
+			weather.ConditionThunderstorm: "⛈️",
+		},
+		wide:   true,
+		arrows: true,
+	},
+	// Nerd Font's weather icons, in the Private Use Area.
+	"nerdfont": {
+		icons: map[weather.Condition]string{
+			weather.ConditionUnknown:      "", // nf-weather-na
+			weather.ConditionClear:        "", // nf-weather-day_sunny
+			weather.ConditionPartlyCloudy: "", // nf-weather-day_cloudy
+			weather.ConditionCloudy:       "", // nf-weather-cloudy
+			weather.ConditionFog:          "", // nf-weather-fog
+			weather.ConditionHaze:         "", // nf-weather-day_haze
+			weather.ConditionDrizzle:      "", // nf-weather-sprinkle
+			weather.ConditionRain:         "", // nf-weather-rain
+			weather.ConditionFreezingRain: "", // nf-weather-rain_mix
+			weather.ConditionSleet:        "", // nf-weather-sleet
+			weather.ConditionSnow:         "", // nf-weather-snow
+			weather.ConditionThunderstorm: "", // nf-weather-thunderstorm
+		},
+		arrows: true,
+	},
+	"ascii": {
+		icons: map[weather.Condition]string{
+			weather.ConditionUnknown:      "?",
+			weather.ConditionClear:        "*",
+			weather.ConditionPartlyCloudy: "~*",
+			weather.ConditionCloudy:       "~~",
+			weather.ConditionFog:          "==",
+			weather.ConditionHaze:         "::",
+			weather.ConditionDrizzle:      "'",
+			weather.ConditionRain:         "//",
+			weather.ConditionFreezingRain: "/#",
+			weather.ConditionSleet:        "/*",
+			weather.ConditionSnow:         "**",
+			weather.ConditionThunderstorm: "/!",
+		},
+	},
+}
+
+// parseIconSet returns the icon set named name.
+func parseIconSet(name string) (*iconSet, error) {
+	set, ok := iconSets[strings.ToLower(name)]
+	if !ok {
+		var names []string
+		for n := range iconSets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown icon set %q (want %s)", name, strings.Join(names, ", "))
+	}
+	return set, nil
+}
+
+// icon returns the glyph for c, two columns wide, followed by a space.
+func (s *iconSet) icon(c weather.Condition) string {
+	icon, ok := s.icons[c]
+	if !ok {
+		icon = s.icons[weather.ConditionUnknown]
+	}
+	if !s.wide {
+		icon = padRight(icon, 2)
+	}
+	return icon + " "
+}
+
+// conditionIcon returns the -icons glyph for c, with a space after it, or ""
+// without -icons.
+func conditionIcon(c weather.Condition, opts *displayOptions) string {
+	if opts.icons == nil {
+		return ""
+	}
+	return opts.icons.icon(c)
+}
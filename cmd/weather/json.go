@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// batchResultJSON is how one location of a -locations-file run is written
+// with -format=json.
+type batchResultJSON struct {
+	Location string                  `json:"location"`
+	Current  *weather.CurrentWeather `json:"current,omitempty"`
+	Forecast *weather.Forecast       `json:"forecast,omitempty"`
+	Error    string                  `json:"error,omitempty"`
+}
+
+func newBatchResultJSON(r weather.BatchResult) batchResultJSON {
+	out := batchResultJSON{
+		Location: r.Location,
+		Current:  r.Current,
+		Forecast: r.Forecast,
+	}
+	if r.Err != nil {
+		out.Error = r.Err.Error()
+	}
+	return out
+}
+
+// printNDJSON writes v as a single line of compact JSON, for -format=ndjson.
+func printNDJSON(v interface{}) error {
+	return writeNDJSON(os.Stdout, v)
+}
+
+func printJSON(v interface{}) error {
+	return writeJSON(os.Stdout, v)
+}
+
+func writeNDJSON(out io.Writer, v interface{}) error {
+	if err := json.NewEncoder(out).Encode(v); err != nil {
+		return fmt.Errorf("error writing JSON: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(out io.Writer, v interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("error writing JSON: %v", err)
+	}
+	return nil
+}
+
+// errorJSON is how an error is written, to stderr, with -format=json or
+// ndjson, so scripts can tell the kinds of error apart by code.
+type errorJSON struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// errorCode returns the errorJSON code for err: invalid_location,
+// location_not_found, not_supported, provider_timeout, no_api_key, or error
+// for any other.
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, weather.ErrInvalidLocation):
+		return "invalid_location"
+	case errors.Is(err, weather.ErrLocationNotFound):
+		return "location_not_found"
+	case errors.Is(err, weather.ErrNotSupported):
+		return "not_supported"
+	case errors.Is(err, weather.ErrProviderTimeout):
+		return "provider_timeout"
+	case errors.Is(err, errNoAPIKey):
+		return "no_api_key"
+	}
+	return "error"
+}
+
+// reportError prints err after msg, such as "Error getting forecast", and
+// returns the exit status for it, 1. With -format=json or ndjson it's written
+// to stderr as an errorJSON instead.
+func reportError(format, msg string, err error) int {
+	if format == "json" || format == "ndjson" {
+		json.NewEncoder(os.Stderr).Encode(errorJSON{Error: err.Error(), Code: errorCode(err)})
+	} else {
+		fmt.Printf("%s: %v\n", msg, err)
+	}
+	return 1
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// A place name: letters, digits and the punctuation found in names such as
+// "St. John's, NL" or "Winston-Salem, NC".
+var locationNameRE = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} .,'()-]*$`)
+
+func validLocation(location string) bool {
+	if _, _, ok := weather.ParseCoordinates(location); ok {
+		return true
+	}
+	return locationNameRE.MatchString(location)
+}
+
+// readLocationsFile reads one location per line from path. Blank lines and
+// lines starting with '#' are skipped; invalid lines are reported on stderr
+// and skipped rather than failing the whole file.
+func readLocationsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening locations file: %v", err)
+	}
+	defer f.Close()
+
+	var locations []string
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !validLocation(line) {
+			fmt.Fprintf(os.Stderr, "Skipping %s line %d: invalid location %q\n", path, lineNum, line)
+			continue
+		}
+		locations = append(locations, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading locations file: %v", err)
+	}
+
+	return locations, nil
+}
+
+// parseGroup parses the semicolon-separated locations of a group setting,
+// such as "Boston, MA; Cambridge, MA".
+func parseGroup(value string) ([]string, error) {
+	var locations []string
+	for _, location := range strings.Split(value, ";") {
+		location = strings.TrimSpace(location)
+		if location == "" {
+			continue
+		}
+		if !validLocation(location) {
+			return nil, fmt.Errorf("invalid location %q", location)
+		}
+		locations = append(locations, location)
+	}
+	if len(locations) == 0 {
+		return nil, fmt.Errorf("no locations")
+	}
+	return locations, nil
+}
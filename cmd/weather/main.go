@@ -1,158 +1,1291 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"math"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 
 	"github.com/duluk/weather/pkg/weather"
-	"github.com/duluk/weather/pkg/weather/openmeteo"
-	"github.com/duluk/weather/pkg/weather/openweather"
 )
 
-func getAPIKey() (string, error) {
-	if apiKey := os.Getenv("OPENWEATHER_API_KEY"); apiKey != "" {
-		return apiKey, nil
+const cacheTTL = 10 * time.Minute
+
+// displayOptions are the command line settings that affect how results are
+// shown, as opposed to how they are fetched.
+type displayOptions struct {
+	// color is whether to color the output, from -color and the
+	// environment.
+	color      bool
+	filter     weather.DayFilter
+	thresholds weather.TemperatureThresholds
+	// severities rate conditions for advisories and coloring, from the
+	// defaults and the config file's severity settings.
+	severities  weather.ConditionSeverities
+	keepHighLow bool
+	showLegend  bool
+	// icons is the -icons or -icon-set glyph table, or nil for words only.
+	icons    *iconSet
+	suggest  bool
+	briefing bool
+	byWeek   bool
+	// beaufort adds the Beaufort force to wind speeds.
+	beaufort bool
+	// keepToday keeps any entry for today in the forecast table, below the
+	// current weather, which describes it too.
+	keepToday bool
+	// bearingAndDistance always shows where the forecast point is from
+	// coordinates asked for, not only when it's far off.
+	bearingAndDistance bool
+	// since is the earlier reading -since-last compares the current weather
+	// with, if there is one.
+	since *reading
+	// groups are the named groups of locations from the config file, for
+	// -group.
+	groups map[string][]string
+	// lang is the -lang code the provider's descriptions are in, if any.
+	lang string
+	// day is "today", "tomorrow" or a weekday name such as "saturday" to
+	// show only that day of the forecast.
+	day string
+	// offset and days are -offset and -days: the number of forecast days to
+	// skip, and how many to show after them, or 0 for all.
+	offset, days int
+	columns      forecastColumns
+	units        units
+	// statusbarFields are the fields shown by -format=statusbar, in order.
+	statusbarFields []string
+	// timeLayout is the time.Format layout for times of day, from
+	// -time-format.
+	timeLayout string
+}
+
+// The command line flags that override each unit setting in the config file.
+var unitFlags = map[string]string{
+	"-temp-unit":       "temperature_unit",
+	"-wind-unit":       "wind_unit",
+	"-pressure-unit":   "pressure_unit",
+	"-precip-unit":     "precipitation_unit",
+	"-visibility-unit": "visibility_unit",
+	"-snow-unit":       "snow_unit",
+}
+
+// errNoAPIKey is returned by getAPIKeys when no OpenWeather key is set.
+var errNoAPIKey = errors.New("API key not found in environment or config file")
+
+// getAPIKeys returns the OpenWeather API keys, which are used in turn. They're
+// comma-separated in OPENWEATHER_API_KEY, or one per line in the key file.
+func getAPIKeys() ([]string, error) {
+	if keys := splitKeys(strings.Split(os.Getenv("OPENWEATHER_API_KEY"), ",")); len(keys) > 0 {
+		return keys, nil
 	}
 
 	apiKeyFile := os.ExpandEnv("$HOME/.config/weather/openweather_api_key")
 	if _, err := os.Stat(apiKeyFile); err == nil {
 		apiKeyBytes, err := os.ReadFile(apiKeyFile)
 		if err != nil {
-			return "", fmt.Errorf("error reading API key file: %v", err)
+			return nil, fmt.Errorf("error reading API key file: %v", err)
+		}
+		if keys := splitKeys(strings.Split(string(apiKeyBytes), "\n")); len(keys) > 0 {
+			return keys, nil
+		}
+	}
+
+	return nil, errNoAPIKey
+}
+
+// splitKeys trims the keys in list, leaving out blank ones.
+func splitKeys(list []string) []string {
+	var keys []string
+	for _, key := range list {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
 		}
-		return strings.TrimSpace(string(apiKeyBytes)), nil
 	}
+	return keys
+}
 
-	return "", fmt.Errorf("API key not found in environment or config file")
+func displayHeader(out io.Writer, header string, opts *displayOptions) {
+	fmt.Fprintf(out, "%s\n", opts.colorize(ansiBold, header))
+	fmt.Fprintf(out, "%s\n", strings.Repeat("-", displayWidth(header)))
 }
 
-func displayCurrentWeather(w *weather.CurrentWeather) {
-	header := fmt.Sprintf("Weather Summary for %s:", w.Location)
-	fmt.Printf("%s\n", header)
-	fmt.Printf("%s\n", strings.Repeat("-", len(header)))
-	fmt.Printf("Conditions:  %s\n", w.Conditions)
-	fmt.Printf("Temperature: %.1f°F\n", w.Temperature)
-	fmt.Printf("  High:      %.1f°F\n", w.TempMax)
-	fmt.Printf("  Low:       %.1f°F\n", w.TempMin)
-	fmt.Printf("Feels Like:  %.1f°F\n", w.FeelsLike)
-	fmt.Printf("Humidity:    %d%%\n", w.Humidity)
-	fmt.Printf("Wind Speed:  %.1f mph\n", w.WindSpeed)
+func cachedNote(cachedAt time.Time) string {
+	if cachedAt.IsZero() {
+		return ""
+	}
+	return " (cached)"
 }
 
-func displayForecast(f *weather.Forecast) {
-	var header string
+// displayAdvisories shows advisories together, most severe first, colored
+// by severity.
+func displayAdvisories(out io.Writer, advisories []weather.Advisory, opts *displayOptions) {
+	advisories = slices.Clone(advisories)
+	slices.SortStableFunc(advisories, func(a, b weather.Advisory) int {
+		return int(b.Severity) - int(a.Severity)
+	})
+	for _, a := range advisories {
+		fmt.Fprintln(out, opts.colorSeverity(a.Severity, "Advisory: "+a.Message))
+	}
+}
+
+// colorSeverity colors s for sev: bold red for high, yellow for medium, and
+// not at all for less.
+func (opts *displayOptions) colorSeverity(sev weather.Severity, s string) string {
+	switch {
+	case sev >= weather.SeverityHigh:
+		return opts.colorize(ansiBold+ansiRed, s)
+	case sev == weather.SeverityMedium:
+		return opts.colorize(ansiYellow, s)
+	}
+	return s
+}
+
+// rainSoonWithin is how far ahead the current weather looks for rain on
+// the way.
+const rainSoonWithin = 3 * time.Hour
+
+func displayCurrentWeather(out io.Writer, w *weather.CurrentWeather, opts *displayOptions) {
+	displayHeader(out, fmt.Sprintf("Weather Summary for %s%s:", w.Location, cachedNote(w.CachedAt)), opts)
+	displayAdvisories(out, w.Advisories, opts)
+	fmt.Fprintf(out, "Conditions:  %s%s\n", conditionIcon(w.Condition, opts), opts.colorSeverity(opts.severities.Severity(w.Condition), w.Conditions))
+	// Measurements the provider had no usable value for are left out.
+	if w.Available("temperature") {
+		fmt.Fprintf(out, "Temperature: %s%s\n", opts.units.formatTemp(w.Temperature), opts.sinceTemp(w))
+	}
+	if (opts.keepHighLow || !redundantHighLow(w)) && w.Available("temp_max") && w.Available("temp_min") {
+		fmt.Fprintf(out, "  High:      %s\n", opts.units.formatTemp(w.TempMax))
+		fmt.Fprintf(out, "  Low:       %s\n", opts.units.formatTemp(w.TempMin))
+	}
+	if w.Available("feels_like") {
+		fmt.Fprintf(out, "Feels Like:  %s\n", opts.units.formatTemp(w.FeelsLike))
+	}
+	fmt.Fprintf(out, "Humidity:    %d%%\n", w.Humidity)
+	if w.Available("wind_speed") {
+		fmt.Fprintf(out, "Wind Speed:  %s%s%s%s\n", opts.units.formatSpeed(w.WindSpeed), windDirection(w.WindSpeed, w.WindDirection, opts), beaufortForce(w.WindSpeed, opts), opts.sinceWind(w))
+	}
+	if w.Precipitation > 0 {
+		fmt.Fprintf(out, "Precip:      %s (last hour)\n", opts.units.formatPrecip(w.Precipitation))
+	}
+	if w.Pressure > 0 {
+		fmt.Fprintf(out, "Pressure:    %s%s\n", opts.units.formatPressure(w.Pressure), opts.sincePressure(w))
+	}
+	if !weather.IsPrecipitating(w) {
+		if hour, ok := w.NextRain(time.Now(), rainSoonWithin); ok {
+			fmt.Fprintf(out, "Rain expected around %s\n", hour.Format(opts.timeLayout))
+		}
+	}
+	if !w.Sunrise.IsZero() && !w.Sunset.IsZero() {
+		fmt.Fprintf(out, "Sunrise:     %s\n", w.Sunrise.Format(opts.timeLayout))
+		fmt.Fprintf(out, "Sunset:      %s\n", w.Sunset.Format(opts.timeLayout))
+
+		now := time.Now()
+		if now.After(w.Sunrise) {
+			if remaining := weather.DaylightRemaining(now, w.Sunset); remaining > 0 {
+				fmt.Fprintf(out, "Daylight:    %s remaining\n", formatDuration(remaining))
+			}
+		}
+	}
+
+	if w.Elevation != 0 {
+		opts.units.numbers.Fprintf(out, "Elevation:   %.0f m\n", w.Elevation)
+	}
+
+	if opts.suggest {
+		fmt.Fprintf(out, "Suggestion:  %s\n", weather.ClothingHint(w.FeelsLike, w.PrecipProbability, w.WindSpeed))
+	}
+}
+
+// displayAnomaly compares the day's high, or the current temperature if the
+// high isn't known, with the climate normal for the date, and notes a high or
+// low that would be a record for the date.
+func displayAnomaly(out io.Writer, p weather.Provider, location string, w *weather.CurrentWeather, opts *displayOptions) {
+	np, ok := p.(weather.NormalsProvider)
+	if !ok {
+		return
+	}
+
+	date := time.Now()
+	if !w.Sunrise.IsZero() {
+		// The location's date, which can differ from ours.
+		date = w.Sunrise
+	}
+	normal, err := np.ClimateNormal(location, date)
+	if err != nil {
+		fmt.Fprintf(out, "Error getting climate normal: %v\n", err)
+		return
+	}
+
+	observed, label := w.TempMax, "high"
+	if redundantHighLow(w) {
+		observed, label = w.Temperature, "temperature"
+	}
+	fmt.Fprintf(out, "Normal:      High %s, Low %s (%d-%d)\n", opts.units.formatTemp(normal.High),
+		opts.units.formatTemp(normal.Low), normal.FirstYear, normal.LastYear)
+
+	anomaly := weather.TemperatureAnomaly(observed, normal.High)
+	switch {
+	case math.Round(opts.units.tempDelta(anomaly)) == 0:
+		fmt.Fprintf(out, "Anomaly:     today's %s is about normal\n", label)
+	case anomaly > 0:
+		fmt.Fprintf(out, "Anomaly:     today's %s is %s above normal\n", label, opts.units.formatTempDelta(anomaly))
+	default:
+		fmt.Fprintf(out, "Anomaly:     today's %s is %s below normal\n", label, opts.units.formatTempDelta(-anomaly))
+	}
+
+	years := len(normal.History)
+	switch weather.HighRecord(observed, normal.History) {
+	case weather.RecordSet:
+		fmt.Fprintf(out, "Record:      today's %s would be the warmest this date in %d years\n", label, years)
+	case weather.RecordTied:
+		fmt.Fprintf(out, "Record:      today's %s would tie the warmest this date in %d years\n", label, years)
+	}
+	if !redundantHighLow(w) {
+		switch weather.LowRecord(w.TempMin, normal.History) {
+		case weather.RecordSet:
+			fmt.Fprintf(out, "Record:      today's low would be the coldest this date in %d years\n", years)
+		case weather.RecordTied:
+			fmt.Fprintf(out, "Record:      today's low would tie the coldest this date in %d years\n", years)
+		}
+	}
+}
+
+// windDirection describes where the wind is from, as an arrow with -icons or
+// as a compass point otherwise, for appending to its speed. Calm wind has no
+// direction.
+func windDirection(speed float64, degrees int, opts *displayOptions) string {
+	if speed == 0 {
+		return ""
+	}
+	if opts.icons != nil && opts.icons.arrows {
+		return " " + weather.WindArrow(float64(degrees))
+	}
+	return " " + weather.CompassDirection(float64(degrees))
+}
+
+// beaufortForce describes a wind speed's Beaufort force with -beaufort, for
+// appending to the speed and direction.
+func beaufortForce(mph float64, opts *displayOptions) string {
+	if !opts.beaufort {
+		return ""
+	}
+	force, name := weather.Beaufort(mph)
+	return fmt.Sprintf(" (Force %d – %s)", force, name)
+}
+
+// redundantHighLow reports whether the day's high and low add nothing to the
+// current temperature: both zero, which is what a provider leaves when it has
+// no daily data, or both the same as the current temperature.
+func redundantHighLow(w *weather.CurrentWeather) bool {
+	if w.TempMax == 0 && w.TempMin == 0 {
+		return true
+	}
+	return w.TempMax == w.TempMin && w.TempMax == w.Temperature
+}
+
+// formatDuration formats d as hours and minutes, e.g. "4h 12m".
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	if h == 0 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh %dm", h, m)
+}
+
+func displayForecast(out io.Writer, f *weather.Forecast, opts *displayOptions) {
+	if opts.briefing {
+		displayBriefing(out, f, opts)
+		return
+	}
+	if opts.day != "" {
+		displayDay(out, f, opts.day, opts)
+		return
+	}
+
 	if f.Current != nil {
-		displayCurrentWeather(f.Current)
-		fmt.Println()
+		displayCurrentWeather(out, f.Current, opts)
+		fmt.Fprintln(out)
+		// Today is in the current weather above, so the table starts
+		// tomorrow, whether or not the provider includes today.
+		days := *f
+		days.DailyItems = tableDays(f, opts)
+		f = &days
 	} else {
-		header := fmt.Sprintf("Weather Summary for %s:", f.Location)
-		fmt.Printf("%s\n", header)
-		fmt.Printf("%s\n", strings.Repeat("-", len(header)))
+		displayHeader(out, fmt.Sprintf("Weather Summary for %s%s:", f.Location, cachedNote(f.CachedAt)), opts)
 	}
 
-	header = fmt.Sprintf("5-Day Forecast for %s:", f.Location)
-	fmt.Printf("%s\n", header)
-	fmt.Printf("%s\n", strings.Repeat("-", len(header)))
+	displayHeader(out, fmt.Sprintf("%d-Day Forecast for %s%s:", len(f.DailyItems), f.Location, cachedNote(f.CachedAt)), opts)
+	displayAdvisories(out, f.Advisories, opts)
+
+	if opts.byWeek {
+		for i, week := range f.Weeks() {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintln(out, opts.colorize(ansiBold, "Week of "+week.Start.Format("Mon 2006-01-02")))
+			for _, day := range week.Days {
+				displayForecastDay(out, day, opts)
+			}
+			fmt.Fprintf(out, "  Average High: %s  Low: %s  Rainy days: %d of %d\n",
+				opts.units.formatTemp(week.AvgHigh), opts.units.formatTemp(week.AvgLow), week.RainyDays, len(week.Days))
+		}
+		return
+	}
 
 	for _, day := range f.DailyItems {
-		fmt.Printf("%s %s: ",
-			day.Date.Format("Mon"),
-			day.Date.Format("2006-01-02"))
-		fmt.Printf("%-25s High: %4.1f°F  Low: %4.1f°F ",
-			cases.Title(language.English).String(day.Conditions),
-			day.High,
-			day.Low)
+		displayForecastDay(out, day, opts)
+	}
+}
+
+// displayForecastDay shows one row of the forecast table, unless the -filter
+// excludes the day.
+func displayForecastDay(out io.Writer, day weather.DailyForecast, opts *displayOptions) {
+	if opts.filter != nil && !opts.filter.Match(day) {
+		return
+	}
+	fmt.Fprintf(out, "%s %s: ",
+		day.Date.Format("Mon"),
+		day.Date.Format("2006-01-02"))
+	fmt.Fprintf(out, "%s High: %s  Low: %s ",
+		conditionIcon(day.Condition, opts)+opts.colorSeverity(opts.severities.Severity(day.Condition), padRight(titleConditions(day.Conditions, opts), 25)),
+		dayTemp(day, "high", day.High, opts), dayTemp(day, "low", day.Low, opts))
+	if opts.columns.wind {
+		// Wide enough for e.g. "12.5 mph NW" so the columns after it line
+		// up.
+		wind := "-"
 		if day.WindSpeed > 0 {
-			fmt.Printf(" Max winds: %4.1f mph ", day.WindSpeed)
+			wind = opts.units.numbers.Sprintf("%4.1f %s%s", opts.units.speed(day.WindSpeed), opts.units.speedSymbol(),
+				padRight(windDirection(day.WindSpeed, day.WindDirection, opts), 3))
 		}
+		fmt.Fprintf(out, " Max winds: %s ", padRight(wind, 8+displayWidth(opts.units.speedSymbol())))
+	}
+	if opts.columns.humidity {
+		humidity := "-"
 		if day.Humidity > 0 {
-			fmt.Printf(" Humidity: %d%%", day.Humidity)
+			humidity = fmt.Sprintf("%d%%", day.Humidity)
+		}
+		fmt.Fprintf(out, " Humidity: %s", padRight(humidity, 4))
+	}
+	if opts.columns.precip {
+		fmt.Fprintf(out, " Precip: %d%%", day.PrecipProbability)
+		if day.Snowfall > 0 {
+			fmt.Fprintf(out, " (%s snow)", opts.units.formatSnow(day.Snowfall))
 		}
-		fmt.Println()
 	}
+	fmt.Fprintln(out)
 }
 
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: weather <zipcode or city,state> [forecast] [-test] [-debug] [-provider=<name>]")
-		fmt.Println("Examples: weather 02108")
-		fmt.Println("          weather \"Boston,MA\"")
-		fmt.Println("          weather \"Boston,MA\" forecast")
-		fmt.Println("          weather \"Boston,MA\" forecast -test")
-		fmt.Println("          weather \"Boston,MA\" -provider=openmeteo")
-		return
+// titleConditions title-cases a description of the conditions, such as
+// "partly cloudy", for the forecast table. Only all-lowercase English is
+// changed: text that already has capitals is taken to be as the provider
+// meant, and English casing rules would mangle other languages.
+func titleConditions(conditions string, opts *displayOptions) string {
+	if opts.lang != "" && !strings.EqualFold(opts.lang, "en") {
+		return conditions
+	}
+	if conditions != strings.ToLower(conditions) {
+		return conditions
+	}
+	return cases.Title(language.English).String(conditions)
+}
+
+// dayTemp formats a forecast day's high or low for the table, or "-" if the
+// provider had no usable value for it.
+func dayTemp(day weather.DailyForecast, name string, f float64, opts *displayOptions) string {
+	if !day.Available(name) {
+		return padRight("-", 4+displayWidth(opts.units.tempSymbol()))
+	}
+	return opts.units.numbers.Sprintf("%4.1f%s", opts.units.temp(f), opts.units.tempSymbol())
+}
+
+func displayLegend(out io.Writer, opts *displayOptions) {
+	entries := opts.units.legend()
+	if opts.icons != nil && opts.icons.arrows {
+		entries = append(entries, [2]string{"↘", "wind direction, the way the wind is blowing"})
+	} else {
+		entries = append(entries, [2]string{"NW", "wind direction, where the wind comes from"})
+	}
+
+	fmt.Fprintln(out, "Legend:")
+	for _, entry := range entries {
+		fmt.Fprintf(out, "  %s %s\n", padRight(entry[0], 6), entry[1])
+	}
+}
+
+// adviseResult adds the advisories for the -heat-threshold and
+// -cold-threshold settings and the condition severities to the weather in r,
+// if it has any.
+func adviseResult(r weather.BatchResult, opts *displayOptions) {
+	switch {
+	case r.Forecast != nil:
+		r.Forecast.Advise(opts.thresholds, opts.severities)
+	case r.Current != nil:
+		r.Current.Advise(opts.thresholds)
+	}
+}
+
+// displayBatch shows the results of a -locations-file run, one location after
+// another, as a JSON array, or as one CSV or table. The error is from writing
+// the CSV, table or JSON.
+func displayBatch(results []weather.BatchResult, opts *displayOptions, format string) error {
+	if format == "csv" || format == "table" {
+		var current []*weather.CurrentWeather
+		var forecasts []*weather.Forecast
+		for _, r := range results {
+			switch {
+			case r.Err != nil:
+				// Not in the table, so it still parses.
+				fmt.Fprintf(os.Stderr, "%s failed: %v\n", r.Location, r.Err)
+			case r.Forecast != nil:
+				forecasts = append(forecasts, r.Forecast)
+			default:
+				current = append(current, r.Current)
+			}
+		}
+		tbl := currentTable(opts, format == "csv", current...)
+		if forecasts != nil {
+			tbl = forecastTable(opts, format == "csv", forecasts...)
+		}
+		write := writeTable
+		if format == "csv" {
+			write = writeCSV
+		}
+		return write(os.Stdout, tbl)
+	}
+	if format == "prometheus" {
+		var current []*weather.CurrentWeather
+		for _, r := range results {
+			if r.Err != nil {
+				// A comment, so the output still parses.
+				fmt.Printf("# %s failed: %s\n", r.Location, strings.ReplaceAll(r.Err.Error(), "\n", " "))
+				continue
+			}
+			current = append(current, r.Current)
+		}
+		displayPrometheus(os.Stdout, current)
+		return nil
 	}
+	if format == "json" {
+		out := make([]batchResultJSON, 0, len(results))
+		for _, r := range results {
+			out = append(out, newBatchResultJSON(r))
+		}
+		return printJSON(out)
+	}
+
+	for i, r := range results {
+		if i > 0 {
+			fmt.Println()
+		}
+		switch {
+		case r.Err != nil:
+			fmt.Println(opts.colorize(ansiBold+ansiRed, fmt.Sprintf("[FAILED] %s: %v", r.Location, r.Err)))
+		case r.Forecast != nil:
+			displayForecast(os.Stdout, r.Forecast, opts)
+		default:
+			displayCurrentWeather(os.Stdout, r.Current, opts)
+		}
+	}
+	return nil
+}
+
+// coordinatesFromFlags returns the "lat,long" location for the -lat and -lng
+// values, which must both be given.
+func coordinatesFromFlags(latFlag, lngFlag string) (string, error) {
+	if latFlag == "" || lngFlag == "" {
+		return "", fmt.Errorf("-lat and -lng must be given together")
+	}
+	lat, err := strconv.ParseFloat(latFlag, 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return "", fmt.Errorf("invalid -lat: %s (want a latitude from -90 to 90)", latFlag)
+	}
+	lng, err := strconv.ParseFloat(lngFlag, 64)
+	if err != nil || lng < -180 || lng > 180 {
+		return "", fmt.Errorf("invalid -lng: %s (want a longitude from -180 to 180)", lngFlag)
+	}
+	return strconv.FormatFloat(lat, 'f', -1, 64) + "," + strconv.FormatFloat(lng, 'f', -1, 64), nil
+}
 
-	location := os.Args[1]
+func usage() {
+	fmt.Println("Usage: weather <zipcode, city,state or lat,long> [forecast|today|tomorrow|<weekday>] [options]")
+	fmt.Println("       weather -lat=<latitude> -lng=<longitude> [forecast|today|tomorrow|<weekday>] [options]")
+	fmt.Println("       weather -locations-file=<file> [forecast] [options]")
+	fmt.Println("       weather -group=<name> [options]")
+	fmt.Println("       weather -serve=<address> [options]")
+	fmt.Println("       weather -save-config <key>=<value>...   set defaults in the config file,")
+	fmt.Println("                                               e.g. units=metric country=FR")
+	fmt.Println("Options:")
+	fmt.Println("  -provider=<name>             openmeteo (default; alias om) or openweather (ow), or")
+	fmt.Println("                               several, e.g. ow,om, to try in turn until one works")
+	fmt.Println("  -provider-timeout=<duration> how long each of several providers gets before the")
+	fmt.Println("                               next is tried, e.g. 5s")
+	fmt.Println("  -profile=<name>              use the [profile.<name>] settings in the config file,")
+	fmt.Println("                               which may set the location, provider and units")
+	fmt.Println("  -merge-providers             with several providers, ask them all at once and")
+	fmt.Println("                               average their weather, taking the conditions most")
+	fmt.Println("                               of them report")
+	fmt.Println("  -fallback-free               use openmeteo, which needs no key, if the chosen")
+	fmt.Println("                               provider's API key isn't set")
+	fmt.Println("  -format=<format>             text (default), json, ndjson (one line per location")
+	fmt.Println("                               as each one completes), csv (numbers in the")
+	fmt.Println("                               display units), table (aligned columns),")
+	fmt.Println("                               statusbar (one ASCII line, e.g. \"52F 12mph\", with")
+	fmt.Println("                               no newline) or prometheus (current weather as")
+	fmt.Println("                               gauges for a node_exporter textfile collector)")
+	fmt.Println("  -statusbar-fields=<fields>   fields and order for -format=statusbar, from temp,")
+	fmt.Println("                               feels, high, low, wind, dir, humidity, cond")
+	fmt.Println("                               (default temp,wind)")
+	fmt.Println("  -locations-file=<file>       fetch each location in file (one per line, '#'")
+	fmt.Println("                               comments allowed) concurrently")
+	fmt.Println("  -group=<name>                current temperatures across the locations of a")
+	fmt.Println("                               group in the config file, e.g.")
+	fmt.Println("                               group.boston = Boston, MA; Cambridge, MA")
+	fmt.Println("  -serve=<address>             serve GET /weather?location=<location>[&forecast=true]")
+	fmt.Println("                               as JSON on address, e.g. :8080; responses are")
+	fmt.Println("                               cached in memory unless -cache is given")
+	fmt.Println("  -color=<always|never|auto>   colored output; NO_COLOR is respected")
+	fmt.Println("  -cache                       cache API responses for a few minutes")
+	fmt.Println("  -extended                    16-day forecast (openweather paid plans)")
+	fmt.Println("  -week                        seven-day forecast (openmeteo, or openweather with")
+	fmt.Println("                               -extended)")
+	fmt.Println("  -filter=<conditions>         only show forecast days matching all conditions,")
+	fmt.Println("                               e.g. 'high>70,precip<30' in the display units")
+	fmt.Println("                               (fields: high, low, wind, humidity, precip %;")
+	fmt.Println("                               comparators: < <= > >= = !=)")
+	fmt.Println("  -by-week                     group the forecast by calendar week, with weekly")
+	fmt.Println("                               averages and a count of rainy days")
+	fmt.Println("  -show=<columns>              forecast columns to show: any of wind, humidity,")
+	fmt.Println("                               precip (default wind,humidity)")
+	fmt.Println("  -lang=<code>                 language for weather descriptions, e.g. de, fr")
+	fmt.Println("                               (openweather only)")
+	fmt.Println("  -lat=<latitude>, -lng=<longitude>")
+	fmt.Println("                               coordinates to use instead of a location argument")
+	fmt.Println("  -zip-fallback=<zip>          zip code to look up instead if the location isn't")
+	fmt.Println("                               found (openmeteo only)")
+	fmt.Println("  -resolve-name                look up a place name for lat,long locations")
+	fmt.Println("                               (openweather only; costs an extra API call)")
+	fmt.Println("  -temp-unit=<F|C|K>           temperature unit (default F)")
+	fmt.Println("  -wind-unit=<unit>            mph (default), kph, m/s or knots")
+	fmt.Println("  -pressure-unit=<unit>        inHg (default) or hPa")
+	fmt.Println("  -precip-unit=<unit>          in (default) or mm")
+	fmt.Println("  -visibility-unit=<unit>      mi (default) or km")
+	fmt.Println("  -snow-unit=<unit>            in (default) or cm")
+	fmt.Println("  -si, -us                     all metric (°C, m/s, hPa, mm, km, cm) or all US units")
+	fmt.Println("  -ski                         °C, km/h and snow in cm")
+	fmt.Println("                               (the unit defaults can be set in the config file,")
+	fmt.Println("                               ~/.config/weather/config, as e.g. wind_unit = kph)")
+	fmt.Println("  -heat-threshold=<°F>         feels-like temperature to warn of extreme heat (105)")
+	fmt.Println("  -cold-threshold=<°F>         feels-like temperature to warn of extreme cold (0)")
+	fmt.Println("  -keep-high-low               show the day's high/low even when they are missing")
+	fmt.Println("                               or the same as the current temperature")
+	fmt.Println("  -time-format=<12h|24h>       how times of day are shown (default from the locale)")
+	fmt.Println("  -icons                       use symbols, such as wind direction arrows")
+	fmt.Println("  -icon-set=<set>              symbols for -icons (which it implies): emoji")
+	fmt.Println("                               (default), nerdfont or ascii")
+	fmt.Println("  -raining                     print yes and exit 0 if it's raining or snowing,")
+	fmt.Println("                               otherwise no and exit 1 (2 on error)")
+	fmt.Println("  -briefing                    a few sentences on the current weather, the trend")
+	fmt.Println("                               and the best day ahead instead of the forecast")
+	fmt.Println("  -anomaly                     compare today's high with the 1991-2020 average")
+	fmt.Println("                               (openmeteo only; cached for a month)")
+	fmt.Println("  -beaufort                    add the Beaufort force to wind speeds, e.g.")
+	fmt.Println("                               \"Force 4 – Moderate breeze\"")
+	fmt.Println("  -suggest                     suggest what to wear for the current weather")
+	fmt.Println("  -legend                      explain the units and symbols after the output")
+	fmt.Println("  -attribution                 credit the weather data source after the output")
+	fmt.Println("  -watch=<interval>            fetch again every interval (e.g. 15m, at least 1m),")
+	fmt.Println("                               redrawing only when the weather has changed")
+	fmt.Println("  -dry-run                     print the API request URLs and exit without fetching")
+	fmt.Println("  -schema                      print the JSON Schema of -format=json output (of")
+	fmt.Println("                               the forecast with forecast) and exit")
+	fmt.Println("  -min-readings=<n>            openweather: leave out days of the 5-day forecast")
+	fmt.Println("                               with fewer than n of the 3-hourly readings from")
+	fmt.Println("                               6am to midnight, 1 to 6 (default 4)")
+	fmt.Println("  -offset=<n>                  start the forecast n days later, such as")
+	fmt.Println("                               -offset=2 -days=5 for days 3 to 7")
+	fmt.Println("  -days=<n>                    show only n days of the forecast")
+	fmt.Println("  -landmarks                   openmeteo: let a location be a park, mountain,")
+	fmt.Println("                               neighborhood or other landmark, not only a city")
+	fmt.Println("                               or town")
+	fmt.Println("  -resolution=<step>           openmeteo: hourly (default) or 15min steps for")
+	fmt.Println("                               the rain expected in the next few hours")
+	fmt.Println("  -no-forecast-current-dedup   keep today in the forecast table below the")
+	fmt.Println("                               current weather, if the provider includes it")
+	fmt.Println("  -since-last[=<window>]       show how the temperature, wind and pressure")
+	fmt.Println("                               have changed since the last run for the")
+	fmt.Println("                               location, if it was within window (default 24h)")
+	fmt.Println("  -bearing-and-distance        with coordinates, show how far and which way the")
+	fmt.Println("                               forecast point is from them (noted anyway if")
+	fmt.Println("                               more than 5 km)")
+	fmt.Println("  -explain                     show how the location was resolved to a place,")
+	fmt.Println("                               and why, instead of the weather")
+	fmt.Println("  -test                        replay the responses saved with -record instead of")
+	fmt.Println("                               fetching (and openweather's older test files,")
+	fmt.Println("                               weather.weather.json and weather.forecast.json)")
+	fmt.Println("  -record                      save each response to a JSON file in the current")
+	fmt.Println("                               directory named by provider and endpoint, e.g.")
+	fmt.Println("                               openmeteo.geocode.json, for -test")
+	fmt.Println("  -show-key                    show the API key in -debug, -dry-run and -explain")
+	fmt.Println("                               URLs instead of ***")
+	fmt.Println("  -verbose                     report how long each API request took, and any")
+	fmt.Println("                               API calls remaining that the provider reports")
+	fmt.Println("  -throttle                    openweather: slow down as the plan's remaining")
+	fmt.Println("                               API calls run low, instead of being rate limited")
+	fmt.Println("  -debug                       print debugging output")
+	fmt.Println("Examples: weather 02108")
+	fmt.Println("          weather \"Boston,MA\"")
+	fmt.Println("          weather \"Boston,MA\" forecast")
+	fmt.Println("          weather \"Boston,MA\" tomorrow")
+	fmt.Println("          weather \"Boston,MA\" forecast saturday")
+	fmt.Println("          weather \"Boston,MA\" forecast -test")
+	fmt.Println("          weather \"Boston,MA\" forecast -filter='high>50'")
+	fmt.Println("          weather \"Boston,MA\" -provider=ow")
+	fmt.Println("          weather 42.36,-71.06 -provider=openweather -resolve-name")
+	fmt.Println("          weather 02108 -raining && echo 'take an umbrella'")
+	fmt.Println("          weather -locations-file=cities.txt -format=json")
+	fmt.Println("          weather -locations-file=cities.txt -format=ndjson | jq .location")
+}
+
+func main() {
+	os.Exit(run())
+}
+
+// run is the command, returning its exit status, so that its deferred calls
+// run before main exits.
+func run() int {
+	var location string
+	haveLocation := false
 	wantForecast := false
-	useTestData := false
-	debugMode := false
 	providerName := "openmeteo"
+	providerSet := false
+	profileName := ""
+	colorMode := "auto"
+	useCache := false
+	// sinceWindow is -since-last's window, or 0 without it.
+	var sinceWindow time.Duration
+	showAttribution := false
+	format := "text"
+	locationsFile := ""
+	groupName := ""
+	serveAddr := ""
+	var latFlag, lngFlag string
+	fetch := &fetchOptions{}
+	display := &displayOptions{
+		thresholds:      weather.DefaultTemperatureThresholds,
+		severities:      maps.Clone(weather.DefaultConditionSeverities),
+		columns:         defaultColumns,
+		units:           defaultUnits,
+		statusbarFields: defaultStatusbarFields,
+	}
+	flagUnits := make(map[string]string)
+	statusbarFieldsFlag := ""
+	timeFormat := localeTimeFormat()
+	raining := false
+	explain := false
+	schema := false
+	fallbackFree := false
+	var watchInterval time.Duration
+	var providerTimeout time.Duration
+	verbose := false
+	replay, record := false, false
+
+	if len(os.Args) > 1 && os.Args[1] == "-save-config" {
+		if err := saveConfig(configPath(), os.Args[2:]); err != nil {
+			return reportError(format, "Error", err)
+		}
+		fmt.Printf("Saved to %s\n", configPath())
+		return 0
+	}
 
-	for i := 2; i < len(os.Args); i++ {
-		arg := os.Args[i]
+	// The format is found first, so errors in the flags before it are
+	// reported in it too.
+	for _, arg := range os.Args[1:] {
+		if strings.HasPrefix(arg, "-format=") {
+			format = strings.TrimPrefix(arg, "-format=")
+		}
+	}
+
+	for _, arg := range os.Args[1:] {
 		if strings.HasPrefix(arg, "-provider=") {
 			providerName = strings.TrimPrefix(arg, "-provider=")
+			providerSet = true
+			continue
+		}
+		if strings.HasPrefix(arg, "-profile=") {
+			profileName = strings.TrimPrefix(arg, "-profile=")
+			if profileName == "" {
+				return reportError(format, "Error", errors.New("-profile needs a name"))
+			}
+			continue
+		}
+		if strings.HasPrefix(arg, "-color=") {
+			colorMode = strings.TrimPrefix(arg, "-color=")
+			continue
+		}
+		if strings.HasPrefix(arg, "-format=") {
+			continue
+		}
+		if strings.HasPrefix(arg, "-lang=") {
+			fetch.lang = strings.TrimPrefix(arg, "-lang=")
+			continue
+		}
+		if strings.HasPrefix(arg, "-lat=") {
+			latFlag = strings.TrimPrefix(arg, "-lat=")
+			continue
+		}
+		if strings.HasPrefix(arg, "-lng=") {
+			lngFlag = strings.TrimPrefix(arg, "-lng=")
+			continue
+		}
+		if strings.HasPrefix(arg, "-zip-fallback=") {
+			fetch.zipFallback = strings.TrimPrefix(arg, "-zip-fallback=")
+			if !regexp.MustCompile(`^[0-9]{5}$`).MatchString(fetch.zipFallback) {
+				return reportError(format, "Error", fmt.Errorf("invalid -zip-fallback: %s (want a 5-digit zip code)", fetch.zipFallback))
+			}
+			continue
+		}
+		if strings.HasPrefix(arg, "-locations-file=") {
+			locationsFile = strings.TrimPrefix(arg, "-locations-file=")
+			continue
+		}
+		if strings.HasPrefix(arg, "-min-readings=") {
+			value := strings.TrimPrefix(arg, "-min-readings=")
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 6 {
+				return reportError(format, "Error", fmt.Errorf("invalid -min-readings: %s (want 1 to 6)", value))
+			}
+			fetch.minReadings = n
+			continue
+		}
+		if strings.HasPrefix(arg, "-offset=") || strings.HasPrefix(arg, "-days=") {
+			name, value, _ := strings.Cut(arg, "=")
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 || name == "-days" && n < 1 {
+				return reportError(format, "Error", fmt.Errorf("invalid %s: %s", name, value))
+			}
+			if name == "-offset" {
+				display.offset = n
+			} else {
+				display.days = n
+			}
+			continue
+		}
+		if strings.HasPrefix(arg, "-resolution=") {
+			switch value := strings.TrimPrefix(arg, "-resolution="); value {
+			case "hourly":
+				fetch.quarterHourly = false
+			case "15min":
+				fetch.quarterHourly = true
+			default:
+				return reportError(format, "Error", fmt.Errorf("invalid -resolution: %s (want hourly or 15min)", value))
+			}
+			continue
+		}
+		if strings.HasPrefix(arg, "-icon-set=") {
+			var err error
+			display.icons, err = parseIconSet(strings.TrimPrefix(arg, "-icon-set="))
+			if err != nil {
+				return reportError(format, "Error", err)
+			}
+			continue
+		}
+		if strings.HasPrefix(arg, "-serve=") {
+			serveAddr = strings.TrimPrefix(arg, "-serve=")
+			continue
+		}
+		if strings.HasPrefix(arg, "-group=") {
+			groupName = strings.TrimPrefix(arg, "-group=")
+			continue
+		}
+		if strings.HasPrefix(arg, "-filter=") {
+			var err error
+			display.filter, err = weather.ParseDayFilter(strings.TrimPrefix(arg, "-filter="))
+			if err != nil {
+				return reportError(format, "Error", err)
+			}
+			continue
+		}
+		if strings.HasPrefix(arg, "-provider-timeout=") {
+			var err error
+			providerTimeout, err = time.ParseDuration(strings.TrimPrefix(arg, "-provider-timeout="))
+			if err != nil || providerTimeout <= 0 {
+				return reportError(format, "Error", fmt.Errorf("invalid -provider-timeout: %s (want a duration such as 5s)",
+					strings.TrimPrefix(arg, "-provider-timeout=")))
+			}
+			continue
+		}
+		if strings.HasPrefix(arg, "-since-last=") {
+			var err error
+			sinceWindow, err = time.ParseDuration(strings.TrimPrefix(arg, "-since-last="))
+			if err != nil || sinceWindow <= 0 {
+				return reportError(format, "Error", fmt.Errorf("invalid -since-last window: %s (want a duration such as 6h)",
+					strings.TrimPrefix(arg, "-since-last=")))
+			}
+			continue
+		}
+		if strings.HasPrefix(arg, "-watch=") {
+			var err error
+			watchInterval, err = time.ParseDuration(strings.TrimPrefix(arg, "-watch="))
+			if err != nil || watchInterval < minWatchInterval {
+				return reportError(format, "Error", fmt.Errorf("invalid -watch interval: %s (want a duration of at least %s, e.g. 15m)",
+					strings.TrimPrefix(arg, "-watch="), minWatchInterval))
+			}
+			continue
+		}
+		if strings.HasPrefix(arg, "-time-format=") {
+			timeFormat = strings.TrimPrefix(arg, "-time-format=")
+			continue
+		}
+		if strings.HasPrefix(arg, "-statusbar-fields=") {
+			statusbarFieldsFlag = strings.TrimPrefix(arg, "-statusbar-fields=")
+			continue
+		}
+		if strings.HasPrefix(arg, "-show=") {
+			var err error
+			display.columns, err = parseColumns(strings.TrimPrefix(arg, "-show="))
+			if err != nil {
+				return reportError(format, "Error", err)
+			}
+			continue
+		}
+		if name, value, ok := strings.Cut(arg, "="); ok && unitFlags[name] != "" {
+			flagUnits[unitFlags[name]] = value
+			continue
+		}
+		if preset, ok := unitPresets[arg]; ok {
+			for key, value := range preset {
+				flagUnits[key] = value
+			}
+			continue
+		}
+		if strings.HasPrefix(arg, "-heat-threshold=") || strings.HasPrefix(arg, "-cold-threshold=") {
+			name, value, _ := strings.Cut(arg, "=")
+			threshold, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return reportError(format, "Error", fmt.Errorf("invalid %s value: %s", name, value))
+			}
+			if name == "-heat-threshold" {
+				display.thresholds.Heat = threshold
+			} else {
+				display.thresholds.Cold = threshold
+			}
 			continue
 		}
 		switch arg {
 		case "forecast":
 			wantForecast = true
+		case "today", "tomorrow", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday":
+			wantForecast = true
+			display.day = arg
 		case "-test":
-			useTestData = true
+			replay = true
+		case "-record":
+			record = true
 		case "-debug":
-			debugMode = true
+			fetch.debugMode = true
+		case "-cache":
+			useCache = true
+		case "-extended":
+			fetch.useExtended = true
+		case "-resolve-name":
+			fetch.resolveName = true
+		case "-attribution":
+			showAttribution = true
+		case "-keep-high-low":
+			display.keepHighLow = true
+		case "-legend":
+			display.showLegend = true
+		case "-icons":
+			if display.icons == nil {
+				display.icons = iconSets["emoji"]
+			}
+		case "-beaufort":
+			display.beaufort = true
+		case "-suggest":
+			display.suggest = true
+		case "-verbose":
+			verbose = true
+		case "-fallback-free":
+			fallbackFree = true
+		case "-anomaly":
+			fetch.anomaly = true
+		case "-raining":
+			raining = true
+		case "-by-week":
+			display.byWeek = true
+		case "-no-forecast-current-dedup":
+			display.keepToday = true
+		case "-landmarks":
+			fetch.landmarks = true
+		case "-merge-providers":
+			fetch.merge = true
+		case "-throttle":
+			fetch.throttle = true
+		case "-bearing-and-distance":
+			display.bearingAndDistance = true
+		case "-since-last":
+			sinceWindow = defaultSinceWindow
+		case "-briefing":
+			display.briefing = true
+			wantForecast = true
+		case "-week":
+			fetch.week = true
+			wantForecast = true
+		case "-dry-run":
+			fetch.dryRun = true
+		case "-show-key":
+			fetch.showKey = true
+		case "-explain":
+			explain = true
+		case "-schema":
+			schema = true
+		default:
+			// The first other argument is the location, which may be
+			// coordinates with a negative latitude such as "-33.87,151.21".
+			if _, _, isCoords := weather.ParseCoordinates(arg); !haveLocation && (!strings.HasPrefix(arg, "-") || isCoords) {
+				location = arg
+				haveLocation = true
+			}
+		}
+	}
+
+	if schema {
+		// The shape of -format=json output, which doesn't need a location.
+		var v any = weather.CurrentWeather{}
+		if wantForecast {
+			v = weather.Forecast{}
+		}
+		if err := printJSON(weather.JSONSchema(v)); err != nil {
+			return reportError(format, "Error", err)
+		}
+		return 0
+	}
+
+	if latFlag != "" || lngFlag != "" {
+		coords, err := coordinatesFromFlags(latFlag, lngFlag)
+		if err == nil && haveLocation {
+			err = fmt.Errorf("give either a location or -lat and -lng, not both")
+		}
+		if err != nil {
+			return reportError(format, "Error", err)
+		}
+		location = coords
+		haveLocation = true
+	}
+
+	config, err := loadConfig(configPath())
+	if err == nil {
+		config, err = selectProfile(config, profileName)
+	}
+	if err != nil {
+		return reportError(format, "Error", err)
+	}
+	if loc := config["location"]; loc != "" && !haveLocation && locationsFile == "" && groupName == "" && serveAddr == "" {
+		location = loc
+		haveLocation = true
+	}
+	if p := config["provider"]; p != "" && !providerSet {
+		providerName = p
+	}
+
+	if replay && record {
+		return reportError(format, "Error", errors.New("give -test or -record, not both"))
+	}
+	if replay || record {
+		fetch.fixtures = &weather.Fixtures{Record: record}
+	}
+
+	if !haveLocation && locationsFile == "" && groupName == "" && serveAddr == "" {
+		usage()
+		return 0
+	}
+	if serveAddr != "" && (haveLocation || locationsFile != "" || groupName != "" || wantForecast) {
+		return reportError(format, "Error", errors.New("-serve takes the location and forecast from each request"))
+	}
+	if groupName != "" && (haveLocation || locationsFile != "" || wantForecast) {
+		return reportError(format, "Error", errors.New("-group shows the current weather of its locations on their own"))
+	}
+	if groupName != "" && format != "text" && format != "json" {
+		return reportError(format, "Error", errors.New("-group supports -format=text and json"))
+	}
+	if haveLocation {
+		if err := weather.CheckLocation(location); err != nil {
+			return reportError(format, "Error", err)
+		}
+		location = inCountry(location, config["country"])
+	}
+	renderer, err := newRenderer(format, display)
+	if err != nil {
+		return reportError(format, "Error", err)
+	}
+	if format == "prometheus" && wantForecast {
+		return reportError(format, "Error", errors.New("-format=prometheus shows the current weather"))
+	}
+	if format == "statusbar" && (locationsFile != "" || wantForecast) {
+		return reportError(format, "Error", errors.New("-format=statusbar shows the current weather for a single location"))
+	}
+	if watchInterval > 0 && (locationsFile != "" || groupName != "" || format != "text" || raining) {
+		return reportError(format, "Error", errors.New("-watch shows text output for a single location"))
+	}
+	if raining && (locationsFile != "" || wantForecast) {
+		return reportError(format, "Error", errors.New("-raining checks the current weather for a single location"))
+	}
+
+	display.timeLayout, err = parseTimeFormat(timeFormat)
+	if err != nil {
+		return reportError(format, "Error", err)
+	}
+
+	display.units.numbers = numberPrinter(fetch.lang)
+	display.lang = fetch.lang
+
+	display.color, err = colorEnabled(colorMode)
+	if err != nil {
+		return reportError(format, "Error", err)
+	}
+
+	if err := applyConfig(config, display); err != nil {
+		return reportError(format, "Error", err)
+	}
+	if transport, ok, err := transportSettings(config); err != nil {
+		return reportError(format, "Error", err)
+	} else if ok {
+		weather.SetTransportSettings(transport)
+	}
+	for key, value := range flagUnits {
+		if err := display.units.set(key, value); err != nil {
+			return reportError(format, "Error", err)
+		}
+	}
+	// -filter is written in the display units, but days are in °F and mph.
+	display.filter = display.filter.Convert(display.units.fromTemp, display.units.fromSpeed)
+	if fetch.week && display.days == 0 {
+		// OpenWeather's extended forecast is longer than a week.
+		display.days = 7
+	}
+	if statusbarFieldsFlag != "" {
+		display.statusbarFields, err = parseStatusbarFields(statusbarFieldsFlag)
+		if err != nil {
+			return reportError(format, "Error", err)
 		}
 	}
 
-	var provider weather.Provider
-	switch providerName {
-	case "openweather":
-		apiKey, err := getAPIKey()
+	var sinceCache weather.Cache
+	if useCache || fetch.anomaly || sinceWindow > 0 {
+		cacheDir, err := os.UserCacheDir()
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-			fmt.Println("Please set the Open Weather API key, either via the environment variable, OPENWEATHER_API_KEY, or a file in ~/.config/weather/openweather_api_key")
-			return
+			return reportError(format, "Error", err)
 		}
-		if debugMode {
-			fmt.Printf("Using Open Weather API key: %s\n", apiKey)
+		cache := weather.NewFileCache(filepath.Join(cacheDir, "weather"))
+		if useCache {
+			fetch.cache = cache
 		}
-		provider = openweather.New(apiKey, useTestData, debugMode)
-	case "openmeteo":
-		if debugMode {
-			fmt.Println("Using Open Meteo API")
+		// Normals hardly change and are a large download, so they are
+		// always cached.
+		fetch.normalsCache = cache
+		sinceCache = cache
+	}
+
+	if serveAddr != "" && fetch.cache == nil {
+		// A server sees the same locations asked for again and again.
+		fetch.cache = weather.NewMemoryCache()
+	}
+
+	if verbose {
+		timings := &requestTimings{}
+		fetch.timing = timings.record
+		defer timings.print()
+	}
+
+	provider, err := newProvider(providerName, fetch, fallbackFree, providerTimeout)
+	if err != nil {
+		return reportError(format, "Error", err)
+	}
+
+	if serveAddr != "" {
+		if err := serve(serveAddr, provider, display); err != nil {
+			return reportError(format, "Error", err)
 		}
-		provider = openmeteo.New(debugMode)
-	default:
-		fmt.Printf("Unknown provider: %s\n", providerName)
-		return
+		return 0
+	}
+
+	locations := []string{location}
+	if locationsFile != "" {
+		locations, err = readLocationsFile(locationsFile)
+		if err != nil {
+			return reportError(format, "Error", err)
+		}
+		if haveLocation {
+			locations = append([]string{location}, locations...)
+		}
+	}
+	if groupName != "" {
+		var ok bool
+		locations, ok = display.groups[groupName]
+		if !ok {
+			return reportError(format, "Error", fmt.Errorf("no group %q in %s (add e.g. group.%s = Boston, MA; Cambridge, MA)",
+				groupName, configPath(), groupName))
+		}
+	}
+	for i, loc := range locations {
+		locations[i] = inCountry(loc, config["country"])
+	}
+
+	if fetch.dryRun {
+		dr, ok := provider.(weather.DryRunner)
+		if !ok {
+			return reportError(format, "Error", fmt.Errorf("provider %s doesn't support -dry-run: %w", providerName, weather.ErrNotSupported))
+		}
+		for _, loc := range locations {
+			for _, u := range dr.RequestURLs(loc, wantForecast) {
+				fmt.Println(u)
+			}
+		}
+		return 0
 	}
 
-	if wantForecast {
+	if explain {
+		ex, ok := provider.(weather.LocationExplainer)
+		if !ok {
+			return reportError(format, "Error", fmt.Errorf("provider %s doesn't support -explain: %w", providerName, weather.ErrNotSupported))
+		}
+		// Every location is explained, even after one fails.
+		status := 0
+		for i, loc := range locations {
+			if i > 0 && format == "text" {
+				fmt.Println()
+			}
+			res, err := ex.ExplainLocation(loc)
+			if err != nil {
+				status = reportError(format, "Error explaining "+loc, err)
+				continue
+			}
+			displayResolution(res)
+		}
+		return status
+	}
+
+	if watchInterval > 0 {
+		return reportError(format, "Error", watchWeather(provider, location, wantForecast, watchInterval, display))
+	}
+
+	out := os.Stdout
+	if groupName != "" {
+		results, _ := weather.FetchBatch(provider, locations, false)
+		for _, r := range results {
+			adviseResult(r, display)
+		}
+		if err := displayGroup(groupName, results, display, format); err != nil {
+			return reportError(format, "Error", err)
+		}
+	} else if locationsFile != "" && format == "ndjson" {
+		for r := range weather.StreamBatch(provider, locations, wantForecast) {
+			adviseResult(r, display)
+			if err := printNDJSON(newBatchResultJSON(r)); err != nil {
+				return reportError(format, "Error", err)
+			}
+		}
+	} else if locationsFile != "" {
+		results, err := weather.FetchBatch(provider, locations, wantForecast)
+		for _, r := range results {
+			adviseResult(r, display)
+		}
+		if err := displayBatch(results, display, format); err != nil {
+			return reportError(format, "Error", err)
+		}
+		if err != nil && format == "text" {
+			failed := 0
+			for _, r := range results {
+				if r.Err != nil {
+					failed++
+				}
+			}
+			fmt.Printf("\n%d of %d locations failed\n", failed, len(locations))
+		}
+	} else if wantForecast {
 		forecast, err := provider.GetForecast(location)
+		if errors.Is(err, weather.ErrNotSupported) {
+			return reportError(format, "Error", fmt.Errorf("provider %s doesn't support forecasts: %w", providerName, err))
+		}
 		if err != nil {
-			fmt.Printf("Error getting forecast: %v\n", err)
-			return
+			return reportError(format, "Error getting forecast", err)
 		}
-		if debugMode {
+		forecast.Advise(display.thresholds, display.severities)
+		if sinceWindow > 0 && forecast.Current != nil {
+			display.since = previousReading(sinceCache, location, forecast.Current, sinceWindow)
+		}
+		if display.offset > 0 || display.days > 0 {
+			if forecast, err = selectDays(forecast, display); err != nil {
+				return reportError(format, "Error", err)
+			}
+		}
+		if fetch.debugMode {
 			fmt.Printf("Current weather: %v\n", forecast)
 		}
 
-		displayForecast(forecast)
+		if err := renderer.RenderForecast(out, forecast); err != nil {
+			return reportError(format, "Error", err)
+		}
+		if format == "text" {
+			displayForecastPoint(out, location, forecast.Current, display)
+		}
 	} else {
 		current, err := provider.GetCurrentWeather(location)
 		if err != nil {
-			fmt.Printf("Error getting current weather: %v\n", err)
-			return
+			if raining {
+				fmt.Printf("Error getting current weather: %v\n", err)
+				// Not "no" either, for scripts testing the status.
+				return 2
+			}
+			return reportError(format, "Error getting current weather", err)
 		}
-		if debugMode {
+		current.Advise(display.thresholds)
+		if sinceWindow > 0 {
+			display.since = previousReading(sinceCache, location, current, sinceWindow)
+		}
+		if fetch.debugMode {
 			fmt.Printf("Current weather: %v\n", current)
 		}
 
-		displayCurrentWeather(current)
+		if raining {
+			if weather.IsPrecipitating(current) {
+				fmt.Println("yes")
+				return 0
+			}
+			fmt.Println("no")
+			return 1
+		}
+
+		if err := renderer.RenderCurrent(out, current); err != nil {
+			return reportError(format, "Error", err)
+		}
+		if format == "text" {
+			displayForecastPoint(out, location, current, display)
+			if fetch.anomaly {
+				displayAnomaly(out, provider, location, current, display)
+			}
+		}
+	}
+
+	if display.showLegend && format == "text" {
+		fmt.Fprintln(out)
+		displayLegend(out, display)
+	}
+	if showAttribution && format == "text" {
+		fmt.Fprintf(out, "\n%s\n", provider.Attribution())
 	}
+	return 0
 }
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// numberPrinter returns the printer for numbers in lang, a -lang code such as
+// "de". Without one it's the LC_NUMERIC locale's language, and English if
+// that isn't set or isn't recognized (as with the C locale).
+func numberPrinter(lang string) *message.Printer {
+	if lang == "" {
+		lang = strings.ReplaceAll(locale("LC_NUMERIC"), "_", "-")
+	}
+	tag, err := language.Parse(lang)
+	if err != nil {
+		tag = language.English
+	}
+	return message.NewPrinter(tag)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// prometheusMetric is one gauge of -format=prometheus output. Values are in
+// the units the providers report, whatever the display units, as Prometheus
+// metric names carry their unit. value's ok is false when the provider had no
+// value for it.
+type prometheusMetric struct {
+	name  string
+	help  string
+	value func(w *weather.CurrentWeather) (float64, bool)
+}
+
+// available returns the value of the measurement with the given JSON name,
+// unless Sanitize found it unusable.
+func available(w *weather.CurrentWeather, name string, v float64) (float64, bool) {
+	return v, w.Available(name)
+}
+
+// nonZero returns v unless it's zero, which for these fields means unknown.
+func nonZero(v float64) (float64, bool) {
+	return v, v != 0
+}
+
+var prometheusMetrics = []prometheusMetric{
+	{"weather_temperature_fahrenheit", "Current temperature.", func(w *weather.CurrentWeather) (float64, bool) {
+		return available(w, "temperature", w.Temperature)
+	}},
+	{"weather_feels_like_fahrenheit", "Current apparent temperature.", func(w *weather.CurrentWeather) (float64, bool) {
+		return available(w, "feels_like", w.FeelsLike)
+	}},
+	{"weather_temperature_max_fahrenheit", "Today's forecast high.", func(w *weather.CurrentWeather) (float64, bool) {
+		return available(w, "temp_max", w.TempMax)
+	}},
+	{"weather_temperature_min_fahrenheit", "Today's forecast low.", func(w *weather.CurrentWeather) (float64, bool) {
+		return available(w, "temp_min", w.TempMin)
+	}},
+	{"weather_humidity_percent", "Current relative humidity.", func(w *weather.CurrentWeather) (float64, bool) {
+		return float64(w.Humidity), true
+	}},
+	{"weather_wind_speed_mph", "Current wind speed.", func(w *weather.CurrentWeather) (float64, bool) {
+		return available(w, "wind_speed", w.WindSpeed)
+	}},
+	{"weather_wind_direction_degrees", "Direction the wind comes from, clockwise from north.", func(w *weather.CurrentWeather) (float64, bool) {
+		return float64(w.WindDirection), w.WindSpeed != 0
+	}},
+	{"weather_precipitation_probability_percent", "Chance of precipitation today.", func(w *weather.CurrentWeather) (float64, bool) {
+		return float64(w.PrecipProbability), true
+	}},
+	{"weather_precipitation_inches", "Rain and snow (as water) in the last hour.", func(w *weather.CurrentWeather) (float64, bool) {
+		return available(w, "precipitation", w.Precipitation)
+	}},
+	{"weather_pressure_inches_of_mercury", "Current air pressure at sea level.", func(w *weather.CurrentWeather) (float64, bool) {
+		if !w.Available("pressure") {
+			return 0, false
+		}
+		return nonZero(w.Pressure)
+	}},
+	{"weather_code", "The provider's code for the current conditions.", func(w *weather.CurrentWeather) (float64, bool) {
+		return float64(w.WeatherCode), true
+	}},
+	{"weather_elevation_meters", "Height of the location above sea level.", func(w *weather.CurrentWeather) (float64, bool) {
+		if !w.Available("elevation") {
+			return 0, false
+		}
+		return nonZero(w.Elevation)
+	}},
+	{"weather_latitude_degrees", "Latitude of the point the weather is for.", func(w *weather.CurrentWeather) (float64, bool) {
+		return w.Latitude, w.Latitude != 0 || w.Longitude != 0
+	}},
+	{"weather_longitude_degrees", "Longitude of the point the weather is for.", func(w *weather.CurrentWeather) (float64, bool) {
+		return w.Longitude, w.Latitude != 0 || w.Longitude != 0
+	}},
+	{"weather_sunrise_timestamp_seconds", "Today's sunrise as a Unix time.", func(w *weather.CurrentWeather) (float64, bool) {
+		return float64(w.Sunrise.Unix()), !w.Sunrise.IsZero()
+	}},
+	{"weather_sunset_timestamp_seconds", "Today's sunset as a Unix time.", func(w *weather.CurrentWeather) (float64, bool) {
+		return float64(w.Sunset.Unix()), !w.Sunset.IsZero()
+	}},
+}
+
+// prometheusLabel escapes s for use as a label value: backslashes, double
+// quotes and newlines are escaped, and other control characters, which some
+// parsers reject, become spaces.
+func prometheusLabel(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < ' ' && r != '\n' || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, strings.TrimSpace(s))
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// displayPrometheus prints the current weather of each location as
+// Prometheus text-format gauges for -format=prometheus, labeled with the
+// location, such as weather_temperature_fahrenheit{location="Boston"} 52.8.
+func displayPrometheus(out io.Writer, ws []*weather.CurrentWeather) {
+	for _, m := range prometheusMetrics {
+		var samples []string
+		for _, w := range ws {
+			v, ok := m.value(w)
+			if !ok {
+				continue
+			}
+			samples = append(samples, fmt.Sprintf("%s{location=\"%s\"} %s",
+				m.name, prometheusLabel(w.Location), strconv.FormatFloat(v, 'f', -1, 64)))
+		}
+		if len(samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(out, "# TYPE %s gauge\n", m.name)
+		for _, s := range samples {
+			fmt.Fprintln(out, s)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/duluk/weather/pkg/weather"
+	"github.com/duluk/weather/pkg/weather/openmeteo"
+	"github.com/duluk/weather/pkg/weather/openweather"
+)
+
+// fetchOptions are the command line settings that affect how results are
+// fetched, which the registry's constructors turn into provider options.
+type fetchOptions struct {
+	// fixtures are for -test and -record, or nil without them.
+	fixtures    *weather.Fixtures
+	debugMode   bool
+	useExtended bool
+	resolveName bool
+	dryRun      bool
+	lang        string
+	showKey     bool
+	zipFallback string
+	// minReadings is -min-readings, or 0 for the provider's default.
+	minReadings int
+	// quarterHourly is -resolution=15min.
+	quarterHourly bool
+	throttle      bool
+	// merge is -merge-providers: blend the providers' weather rather than
+	// using the first to succeed.
+	merge bool
+	// landmarks is -landmarks.
+	landmarks bool
+	// week is -week, for a seven-day forecast.
+	week    bool
+	anomaly bool
+	timing  weather.TimingFunc
+	cache   weather.Cache
+	// normalsCache caches climate normals for -anomaly, even without
+	// -cache.
+	normalsCache weather.Cache
+}
+
+type providerEntry struct {
+	name    string
+	aliases []string
+	new     func(opts *fetchOptions) (weather.Provider, error)
+}
+
+// providerRegistry lists the providers that can be chosen with -provider,
+// by name or by any of their aliases.
+var providerRegistry = []providerEntry{
+	// The first is the default, and what -fallback-free falls back to, so
+	// it must not need an API key.
+	{name: "openmeteo", aliases: []string{"om", "meteo"}, new: newOpenMeteo},
+	{name: "openweather", aliases: []string{"ow", "owm"}, new: newOpenWeather},
+}
+
+// lookupProvider finds the registry entry for a provider name or alias.
+func lookupProvider(name string) (*providerEntry, error) {
+	name = strings.ToLower(name)
+	for i, entry := range providerRegistry {
+		if entry.name == name {
+			return &providerRegistry[i], nil
+		}
+		for _, alias := range entry.aliases {
+			if alias == name {
+				return &providerRegistry[i], nil
+			}
+		}
+	}
+
+	var valid []string
+	for _, entry := range providerRegistry {
+		valid = append(valid, fmt.Sprintf("%s (%s)", entry.name, strings.Join(entry.aliases, ", ")))
+	}
+	sort.Strings(valid)
+	return nil, fmt.Errorf("unknown provider: %s; valid providers (aliases) are: %s", name, strings.Join(valid, "; "))
+}
+
+// newProvider creates the provider for a -provider value. A comma-separated
+// list of names is tried in order, each given at most timeout if it's set,
+// or with -merge-providers all are asked and their weather blended.
+// With fallbackFree, a provider without its API key is replaced by the
+// default one.
+func newProvider(names string, opts *fetchOptions, fallbackFree bool, timeout time.Duration) (weather.Provider, error) {
+	var providers []weather.Provider
+	var used []string
+	for _, name := range strings.Split(names, ",") {
+		entry, err := lookupProvider(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		p, err := entry.new(opts)
+		if errors.Is(err, errNoAPIKey) && fallbackFree {
+			fmt.Fprintf(os.Stderr, "Warning: no %s API key found, using %s instead\n", entry.name, providerRegistry[0].name)
+			entry = &providerRegistry[0]
+			p, err = entry.new(opts)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(used, entry.name) {
+			continue
+		}
+		if err := checkCapabilities(entry.name, p, opts); err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+		used = append(used, entry.name)
+	}
+
+	if len(providers) == 1 {
+		if timeout > 0 {
+			return nil, fmt.Errorf("-provider-timeout needs more than one provider, e.g. -provider=ow,om")
+		}
+		if opts.merge {
+			return nil, fmt.Errorf("-merge-providers needs more than one provider, e.g. -provider=ow,om")
+		}
+		return providers[0], nil
+	}
+	if opts.merge {
+		return weather.NewMergeProvider(providers, weather.WithMergeTimeout(timeout)), nil
+	}
+	return weather.NewMultiProvider(providers, weather.WithProviderTimeout(timeout)), nil
+}
+
+func newOpenMeteo(opts *fetchOptions) (weather.Provider, error) {
+	if opts.minReadings > 0 {
+		return nil, fmt.Errorf("provider openmeteo doesn't support -min-readings")
+	}
+	if opts.throttle {
+		return nil, fmt.Errorf("provider openmeteo doesn't support -throttle")
+	}
+	if opts.debugMode {
+		fmt.Println("Using Open Meteo API")
+	}
+	var pOpts []openmeteo.Option
+	if opts.cache != nil {
+		pOpts = append(pOpts, openmeteo.WithCache(opts.cache, cacheTTL))
+	}
+	if opts.normalsCache != nil {
+		pOpts = append(pOpts, openmeteo.WithNormalsCache(opts.normalsCache))
+	}
+	if opts.timing != nil {
+		pOpts = append(pOpts, openmeteo.WithTiming(opts.timing))
+	}
+	if opts.zipFallback != "" {
+		pOpts = append(pOpts, openmeteo.WithZipFallback(opts.zipFallback))
+	}
+	if opts.quarterHourly {
+		pOpts = append(pOpts, openmeteo.WithQuarterHourly())
+	}
+	if opts.landmarks {
+		pOpts = append(pOpts, openmeteo.WithLandmarks())
+	}
+	if opts.week {
+		pOpts = append(pOpts, openmeteo.WithForecastDays(7))
+	}
+	if opts.fixtures != nil {
+		pOpts = append(pOpts, openmeteo.WithFixtures(opts.fixtures))
+	}
+	return openmeteo.New(opts.debugMode, pOpts...), nil
+}
+
+func newOpenWeather(opts *fetchOptions) (weather.Provider, error) {
+	if opts.zipFallback != "" {
+		return nil, fmt.Errorf("provider openweather doesn't support -zip-fallback")
+	}
+	if opts.quarterHourly {
+		return nil, fmt.Errorf("provider openweather doesn't support -resolution=15min")
+	}
+	if opts.landmarks {
+		return nil, fmt.Errorf("provider openweather doesn't support -landmarks")
+	}
+	if opts.week && !opts.useExtended {
+		return nil, fmt.Errorf("provider openweather supports at most 5 days of forecast; -week needs -extended, and so a paid plan")
+	}
+	apiKeys, err := getAPIKeys()
+	if err != nil && opts.dryRun {
+		// Nothing is fetched, so show where the key would go.
+		apiKeys, err = []string{"{api_key}"}, nil
+	}
+	if err != nil && opts.fixtures.Replaying() {
+		// Nor is anything fetched when replaying fixtures.
+		apiKeys, err = []string{"{api_key}"}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w\nPlease set the Open Weather API key, either via the environment variable, OPENWEATHER_API_KEY, or a file in ~/.config/weather/openweather_api_key. Several keys, to use in turn, can be given comma-separated or one per line", err)
+	}
+	if opts.debugMode {
+		shown := "***"
+		if opts.showKey {
+			shown = strings.Join(apiKeys, ", ")
+		}
+		fmt.Printf("Using %d Open Weather API key(s): %s\n", len(apiKeys), shown)
+	}
+
+	var pOpts []openweather.Option
+	if len(apiKeys) > 1 {
+		pOpts = append(pOpts, openweather.WithAPIKeys(apiKeys[1:]...))
+	}
+	if opts.cache != nil {
+		pOpts = append(pOpts, openweather.WithCache(opts.cache, cacheTTL))
+	}
+	if opts.useExtended {
+		pOpts = append(pOpts, openweather.WithDailyForecast())
+	}
+	if opts.resolveName {
+		pOpts = append(pOpts, openweather.WithResolveName())
+	}
+	if opts.lang != "" {
+		pOpts = append(pOpts, openweather.WithLanguage(opts.lang))
+	}
+	if opts.timing != nil {
+		pOpts = append(pOpts, openweather.WithTiming(opts.timing))
+	}
+	if opts.showKey {
+		pOpts = append(pOpts, openweather.WithShowKey())
+	}
+	if opts.minReadings > 0 {
+		pOpts = append(pOpts, openweather.WithMinReadings(opts.minReadings))
+	}
+	if opts.throttle {
+		pOpts = append(pOpts, openweather.WithThrottle())
+	}
+	if opts.fixtures != nil {
+		pOpts = append(pOpts, openweather.WithFixtures(opts.fixtures))
+	}
+	return openweather.New(apiKeys[0], opts.debugMode, pOpts...), nil
+}
+
+// checkCapabilities returns an error naming the first option in opts that
+// the provider doesn't support.
+func checkCapabilities(name string, p weather.Provider, opts *fetchOptions) error {
+	caps := weather.CapabilitiesOf(p)
+	for _, check := range []struct {
+		used bool
+		flag string
+		cap  weather.Capability
+	}{
+		{opts.useExtended, "-extended", weather.CapExtendedForecast},
+		{opts.resolveName, "-resolve-name", weather.CapReverseGeocoding},
+		{opts.lang != "", "-lang", weather.CapLanguage},
+		{opts.anomaly, "-anomaly", weather.CapClimateNormals},
+		{opts.week, "-week", weather.CapWeekForecast},
+	} {
+		if check.used && !caps.Has(check.cap) {
+			return fmt.Errorf("provider %s doesn't support %s (supports: %s)", name, check.flag, caps)
+		}
+	}
+	return nil
+}
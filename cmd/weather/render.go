@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// Renderer writes the weather for one location in an output format, as
+// chosen with -format.
+type Renderer interface {
+	RenderCurrent(out io.Writer, w *weather.CurrentWeather) error
+	RenderForecast(out io.Writer, f *weather.Forecast) error
+}
+
+// errCurrentOnly is returned by renderers for formats that only show the
+// current weather.
+var errCurrentOnly = errors.New("this format shows the current weather only")
+
+// newRenderer returns the Renderer for format, which shows results with the
+// settings in opts.
+func newRenderer(format string, opts *displayOptions) (Renderer, error) {
+	switch format {
+	case "text":
+		return textRenderer{opts}, nil
+	case "json":
+		return jsonRenderer{}, nil
+	case "ndjson":
+		return ndjsonRenderer{}, nil
+	case "statusbar":
+		return statusbarRenderer{opts}, nil
+	case "prometheus":
+		return prometheusRenderer{}, nil
+	case "csv":
+		return csvRenderer{opts}, nil
+	case "table":
+		return tableRenderer{opts}, nil
+	}
+	return nil, fmt.Errorf("unknown format: %s", format)
+}
+
+// textRenderer is the default, human readable, format.
+type textRenderer struct {
+	opts *displayOptions
+}
+
+func (r textRenderer) RenderCurrent(out io.Writer, w *weather.CurrentWeather) error {
+	displayCurrentWeather(out, w, r.opts)
+	return nil
+}
+
+func (r textRenderer) RenderForecast(out io.Writer, f *weather.Forecast) error {
+	displayForecast(out, f, r.opts)
+	return nil
+}
+
+// jsonRenderer writes indented JSON.
+type jsonRenderer struct{}
+
+func (jsonRenderer) RenderCurrent(out io.Writer, w *weather.CurrentWeather) error {
+	return writeJSON(out, w)
+}
+
+func (jsonRenderer) RenderForecast(out io.Writer, f *weather.Forecast) error {
+	return writeJSON(out, f)
+}
+
+// ndjsonRenderer writes each result as one line of JSON.
+type ndjsonRenderer struct{}
+
+func (ndjsonRenderer) RenderCurrent(out io.Writer, w *weather.CurrentWeather) error {
+	return writeNDJSON(out, w)
+}
+
+func (ndjsonRenderer) RenderForecast(out io.Writer, f *weather.Forecast) error {
+	return writeNDJSON(out, f)
+}
+
+// statusbarRenderer writes the current weather as one short line.
+type statusbarRenderer struct {
+	opts *displayOptions
+}
+
+func (r statusbarRenderer) RenderCurrent(out io.Writer, w *weather.CurrentWeather) error {
+	displayStatusbar(out, w, r.opts)
+	return nil
+}
+
+func (statusbarRenderer) RenderForecast(io.Writer, *weather.Forecast) error {
+	return errCurrentOnly
+}
+
+// prometheusRenderer writes the current weather as Prometheus gauges.
+type prometheusRenderer struct{}
+
+func (prometheusRenderer) RenderCurrent(out io.Writer, w *weather.CurrentWeather) error {
+	displayPrometheus(out, []*weather.CurrentWeather{w})
+	return nil
+}
+
+func (prometheusRenderer) RenderForecast(io.Writer, *weather.Forecast) error {
+	return errCurrentOnly
+}
+
+// csvRenderer writes a header line and a row for the current weather, or for
+// each forecast day, with measurements as numbers in the display units.
+type csvRenderer struct {
+	opts *displayOptions
+}
+
+func (r csvRenderer) RenderCurrent(out io.Writer, w *weather.CurrentWeather) error {
+	return writeCSV(out, currentTable(r.opts, true, w))
+}
+
+func (r csvRenderer) RenderForecast(out io.Writer, f *weather.Forecast) error {
+	return writeCSV(out, forecastTable(r.opts, true, f))
+}
+
+// tableRenderer writes the same rows as csvRenderer in aligned columns, with
+// measurements formatted as in the text output.
+type tableRenderer struct {
+	opts *displayOptions
+}
+
+func (r tableRenderer) RenderCurrent(out io.Writer, w *weather.CurrentWeather) error {
+	return writeTable(out, currentTable(r.opts, false, w))
+}
+
+func (r tableRenderer) RenderForecast(out io.Writer, f *weather.Forecast) error {
+	return writeTable(out, forecastTable(r.opts, false, f))
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// testOptions are the default display options, as main sets them up without
+// any flags or config.
+func testOptions() *displayOptions {
+	return &displayOptions{
+		thresholds: weather.DefaultTemperatureThresholds,
+		severities: weather.DefaultConditionSeverities,
+		columns:    defaultColumns,
+		units:      defaultUnits,
+		timeLayout: "15:04",
+	}
+}
+
+func testCurrent() *weather.CurrentWeather {
+	return &weather.CurrentWeather{
+		Location:          "Springfield",
+		Conditions:        "slight rain",
+		Condition:         weather.ConditionRain,
+		Temperature:       41.5,
+		FeelsLike:         37.2,
+		Humidity:          72,
+		WindSpeed:         14.2,
+		WindDirection:     225,
+		PrecipProbability: 90,
+		Pressure:          29.9,
+		ObservedAt:        time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func testForecast() *weather.Forecast {
+	return &weather.Forecast{
+		Location: "Springfield",
+		DailyItems: []weather.DailyForecast{
+			{Date: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC), Conditions: "moderate snow", High: 31.2, Low: 22.5,
+				WindSpeed: 24.9, Humidity: 88, PrecipProbability: 75, Precipitation: 0.4},
+			{Date: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), Conditions: "clear sky", High: 50, Low: 30.9,
+				WindSpeed: 6.2, Humidity: 60},
+		},
+	}
+}
+
+func render(t *testing.T, format string, opts *displayOptions, v any) string {
+	t.Helper()
+	r, err := newRenderer(format, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	switch v := v.(type) {
+	case *weather.CurrentWeather:
+		err = r.RenderCurrent(&buf, v)
+	case *weather.Forecast:
+		err = r.RenderForecast(&buf, v)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestCSVRendererCurrent(t *testing.T) {
+	opts := testOptions()
+	opts.units.set("temperature_unit", "C")
+	opts.units.set("pressure_unit", "hPa")
+
+	got := render(t, "csv", opts, testCurrent())
+	want := "Location,Observed,Conditions,Temperature (°C),Feels Like (°C),Humidity (%),Wind (mph),Wind From (°),Precip Chance (%),Pressure (hPa)\n" +
+		"Springfield,2025-03-01T12:00:00Z,slight rain,5.3,2.9,72,14.2,225,90,1013\n"
+	if got != want {
+		t.Errorf("csv =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// Numbers in CSV aren't localized, since a decimal comma would split them.
+func TestCSVRendererIgnoresLocale(t *testing.T) {
+	opts := testOptions()
+	opts.units.numbers = numberPrinter("de")
+
+	got := render(t, "csv", opts, testCurrent())
+	if !strings.Contains(got, ",41.5,37.2,") {
+		t.Errorf("csv = %q, want the temperatures with decimal points", got)
+	}
+}
+
+func TestCSVRendererForecastFiltered(t *testing.T) {
+	opts := testOptions()
+	var err error
+	if opts.filter, err = weather.ParseDayFilter("precip>50"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := render(t, "csv", opts, testForecast())
+	want := "Location,Date,Conditions,High (°F),Low (°F),Wind (mph),Humidity (%),Precip Chance (%),Precip (in)\n" +
+		"Springfield,2025-03-02,moderate snow,31.2,22.5,24.9,88,75,0.40\n"
+	if got != want {
+		t.Errorf("csv =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTableRendererForecast(t *testing.T) {
+	got := render(t, "table", testOptions(), testForecast())
+	want := "" +
+		"Location     Date        Conditions     High    Low     Wind      Humidity  Precip Chance  Precip\n" +
+		"Springfield  2025-03-02  moderate snow  31.2°F  22.5°F  24.9 mph  88%       75%            0.40 in\n" +
+		"Springfield  2025-03-03  clear sky      50.0°F  30.9°F  6.2 mph   60%       0%             0.00 in\n"
+	if got != want {
+		t.Errorf("table =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// A measurement the provider didn't have is an empty cell, not a zero.
+func TestTableRendererUnavailable(t *testing.T) {
+	w := testCurrent()
+	w.Temperature = 0
+	w.Unavailable = []string{"temperature"}
+
+	got := render(t, "csv", testOptions(), w)
+	if !strings.Contains(got, "slight rain,,37.2,") {
+		t.Errorf("csv = %q, want an empty temperature", got)
+	}
+}
+
+// The text renderer colors and formats numbers by its options alone.
+func TestTextRendererOptions(t *testing.T) {
+	opts := testOptions()
+	opts.units.numbers = numberPrinter("de")
+
+	plain := render(t, "text", opts, testCurrent())
+	if strings.Contains(plain, "\033[") {
+		t.Errorf("text without color has ANSI escapes:\n%s", plain)
+	}
+	if !strings.Contains(plain, "Temperature: 41,5°F") {
+		t.Errorf("text =\n%s\nwant the temperature as 41,5°F", plain)
+	}
+
+	opts.color = true
+	colored := render(t, "text", opts, testCurrent())
+	if !strings.Contains(colored, ansiBold+"Weather Summary for Springfield:"+ansiReset) {
+		t.Errorf("text with color =\n%q\nwant a bold header", colored)
+	}
+}
+
+func TestStatusbarRendererCurrentOnly(t *testing.T) {
+	r, err := newRenderer("statusbar", testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenderForecast(&bytes.Buffer{}, testForecast()); err != errCurrentOnly {
+		t.Errorf("RenderForecast = %v, want errCurrentOnly", err)
+	}
+}
+
+func TestNewRendererUnknown(t *testing.T) {
+	if _, err := newRenderer("xml", testOptions()); err == nil {
+		t.Error("newRenderer(xml) succeeded, want an error")
+	}
+}
+
+func TestDisplayLegend(t *testing.T) {
+	opts := testOptions()
+	opts.units.set("wind_unit", "kph")
+
+	var buf bytes.Buffer
+	displayLegend(&buf, opts)
+	want := "Legend:\n" +
+		"  °F     degrees Fahrenheit\n" +
+		"  km/h   kilometers per hour\n" +
+		"  %      relative humidity\n" +
+		"  NW     wind direction, where the wind comes from\n"
+	if buf.String() != want {
+		t.Errorf("legend =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestDisplayForecastPoint(t *testing.T) {
+	opts := testOptions()
+	opts.bearingAndDistance = true
+	w := &weather.CurrentWeather{Latitude: 42.45, Longitude: -71.06}
+
+	var buf bytes.Buffer
+	displayForecastPoint(&buf, "42.36,-71.06", w, opts)
+	want := "\nForecast point: 6 mi N of 42.36,-71.06 (42.4500, -71.0600)\n"
+	if buf.String() != want {
+		t.Errorf("forecast point = %q, want %q", buf.String(), want)
+	}
+
+	// Somewhere that isn't coordinates has no forecast point to note.
+	buf.Reset()
+	displayForecastPoint(&buf, "Boston, MA", w, opts)
+	if buf.Len() != 0 {
+		t.Errorf("forecast point for a name = %q, want nothing", buf.String())
+	}
+}
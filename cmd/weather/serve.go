@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// serve runs the HTTP server for -serve on addr until it fails. Its one
+// endpoint, GET /weather, takes the location as ?location= (or ?lat= and
+// ?lng=), and ?forecast=true for the forecast, and answers with the JSON of
+// -format=json, or an errorJSON.
+func serve(addr string, p weather.Provider, opts *displayOptions) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /weather", func(w http.ResponseWriter, r *http.Request) {
+		handleWeather(w, r, p, opts)
+	})
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("Serving weather on %s", addr)
+	return server.ListenAndServe()
+}
+
+func handleWeather(w http.ResponseWriter, r *http.Request, p weather.Provider, opts *displayOptions) {
+	q := r.URL.Query()
+	location := q.Get("location")
+	if q.Has("lat") || q.Has("lng") {
+		coords, err := coordinatesFromFlags(q.Get("lat"), q.Get("lng"))
+		if err == nil && location != "" {
+			err = fmt.Errorf("give either a location or lat and lng, not both")
+		}
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, errorJSON{Error: err.Error(), Code: "bad_request"})
+			return
+		}
+		location = coords
+	}
+	if format := q.Get("format"); format != "" && format != "json" {
+		writeJSONError(w, http.StatusBadRequest, errorJSON{Error: "unsupported format: " + format, Code: "bad_request"})
+		return
+	}
+	forecast := false
+	if v := q.Get("forecast"); v != "" {
+		var err error
+		if forecast, err = strconv.ParseBool(v); err != nil {
+			writeJSONError(w, http.StatusBadRequest, errorJSON{Error: "invalid forecast: " + v, Code: "bad_request"})
+			return
+		}
+	}
+
+	var v any
+	var err error
+	if forecast {
+		var f *weather.Forecast
+		if f, err = weather.ForecastContext(r.Context(), p, location); err == nil {
+			f.Advise(opts.thresholds, opts.severities)
+			v = f
+		}
+	} else {
+		var current *weather.CurrentWeather
+		if current, err = weather.CurrentWeatherContext(r.Context(), p, location); err == nil {
+			current.Advise(opts.thresholds)
+			v = current
+		}
+	}
+	if err != nil {
+		writeJSONError(w, errorStatus(err), errorJSON{Error: err.Error(), Code: errorCode(err)})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+// errorStatus returns the HTTP status for an error getting the weather.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, weather.ErrInvalidLocation):
+		return http.StatusBadRequest
+	case errors.Is(err, weather.ErrLocationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, weather.ErrNotSupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, weather.ErrProviderTimeout):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
+
+func writeJSONError(w http.ResponseWriter, status int, e errorJSON) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(e)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// defaultSinceWindow is how old the previous reading -since-last compares
+// with can be, unless a window is given.
+const defaultSinceWindow = 24 * time.Hour
+
+// reading is what -since-last keeps of a location's current weather between
+// runs. Measurements the provider didn't have are nil.
+type reading struct {
+	At          time.Time `json:"at"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	WindSpeed   *float64  `json:"wind_speed,omitempty"`
+	Pressure    *float64  `json:"pressure,omitempty"`
+}
+
+func readingKey(location string) string {
+	return "reading:" + strings.ToLower(strings.TrimSpace(location))
+}
+
+// readingOf returns w's reading, taken when it was observed, or failing
+// that when it was fetched.
+func readingOf(w *weather.CurrentWeather) reading {
+	r := reading{At: w.ObservedAt}
+	if r.At.IsZero() {
+		r.At = w.CachedAt
+	}
+	if r.At.IsZero() {
+		r.At = time.Now()
+	}
+	if w.Available("temperature") {
+		r.Temperature = &w.Temperature
+	}
+	if w.Available("wind_speed") {
+		r.WindSpeed = &w.WindSpeed
+	}
+	// Zero is a pressure the provider didn't report.
+	if w.Available("pressure") && w.Pressure > 0 {
+		r.Pressure = &w.Pressure
+	}
+	return r
+}
+
+// previousReading returns the reading an earlier run saved for location in
+// c, if it's older than w's but no more than window older, and saves w's in
+// its place. A reading isn't replaced by one of the same time, so running
+// again on a cached response still compares with the reading before it.
+func previousReading(c weather.Cache, location string, w *weather.CurrentWeather, window time.Duration) *reading {
+	current := readingOf(w)
+	var prev *reading
+	if data, ok := c.Get(readingKey(location)); ok {
+		var r reading
+		if json.Unmarshal(data, &r) == nil {
+			prev = &r
+		}
+	}
+	if prev == nil || current.At.After(prev.At) {
+		if data, err := json.Marshal(current); err == nil {
+			c.Set(readingKey(location), data, window)
+		}
+	}
+	if prev == nil || !current.At.After(prev.At) || current.At.Sub(prev.At) > window {
+		return nil
+	}
+	return prev
+}
+
+// sinceNote describes the change from the -since-last reading's value prev
+// to now as " (↓3°F since 1h 0m ago)", formatting its size with format. It's
+// "" without a previous value.
+func sinceNote(prev *float64, now float64, at time.Time, format func(float64) string) string {
+	if prev == nil {
+		return ""
+	}
+	ago := formatDuration(time.Since(at))
+	// Changes that round away in the display unit are no change.
+	delta := now - *prev
+	if change := format(math.Abs(delta)); change != format(0) {
+		arrow := "↑"
+		if delta < 0 {
+			arrow = "↓"
+		}
+		return fmt.Sprintf(" (%s%s since %s ago)", arrow, change, ago)
+	}
+	return fmt.Sprintf(" (unchanged since %s ago)", ago)
+}
+
+// sinceTemp is sinceNote for the temperature.
+func (opts *displayOptions) sinceTemp(w *weather.CurrentWeather) string {
+	if opts.since == nil {
+		return ""
+	}
+	return sinceNote(opts.since.Temperature, w.Temperature, opts.since.At, opts.units.formatTempDelta)
+}
+
+// sinceWind is sinceNote for the wind speed.
+func (opts *displayOptions) sinceWind(w *weather.CurrentWeather) string {
+	if opts.since == nil {
+		return ""
+	}
+	return sinceNote(opts.since.WindSpeed, w.WindSpeed, opts.since.At, func(mph float64) string {
+		return opts.units.numbers.Sprintf("%.0f %s", opts.units.speed(mph), opts.units.speedSymbol())
+	})
+}
+
+// sincePressure is sinceNote for the pressure.
+func (opts *displayOptions) sincePressure(w *weather.CurrentWeather) string {
+	if opts.since == nil {
+		return ""
+	}
+	return sinceNote(opts.since.Pressure, w.Pressure, opts.since.At, opts.units.formatPressure)
+}
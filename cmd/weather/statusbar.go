@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// defaultStatusbarFields are shown by -format=statusbar unless
+// -statusbar-fields or the statusbar_fields config setting says otherwise.
+var defaultStatusbarFields = []string{"temp", "wind"}
+
+// statusbarFields renders each field -format=statusbar can show. Every one is
+// ASCII, without spaces, and rounded to whole numbers so the width only
+// changes when the weather does.
+var statusbarFields = map[string]func(w *weather.CurrentWeather, u units) string{
+	"temp":  func(w *weather.CurrentWeather, u units) string { return statusbarTemp(w.Temperature, u) },
+	"feels": func(w *weather.CurrentWeather, u units) string { return statusbarTemp(w.FeelsLike, u) },
+	"high":  func(w *weather.CurrentWeather, u units) string { return statusbarTemp(w.TempMax, u) },
+	"low":   func(w *weather.CurrentWeather, u units) string { return statusbarTemp(w.TempMin, u) },
+	"wind": func(w *weather.CurrentWeather, u units) string {
+		symbol := u.wind
+		if symbol == "knots" {
+			symbol = "kt"
+		}
+		return fmt.Sprintf("%d%s", round(u.speed(w.WindSpeed)), symbol)
+	},
+	"dir": func(w *weather.CurrentWeather, u units) string {
+		if w.WindSpeed == 0 {
+			return "-"
+		}
+		return weather.CompassDirection(float64(w.WindDirection))
+	},
+	"humidity": func(w *weather.CurrentWeather, u units) string { return fmt.Sprintf("%d%%", w.Humidity) },
+	"cond": func(w *weather.CurrentWeather, u units) string {
+		return strings.ReplaceAll(w.Condition.String(), " ", "-")
+	},
+}
+
+func statusbarTemp(f float64, u units) string {
+	return fmt.Sprintf("%d%s", round(u.temp(f)), u.temperature)
+}
+
+// round rounds x to the nearest integer, avoiding the "-0" that formatting
+// small negative numbers with %.0f gives.
+func round(x float64) int {
+	return int(math.Round(x))
+}
+
+// parseStatusbarFields parses a comma-separated list of field names such as
+// "temp,humidity,wind", in the order they are to be shown.
+func parseStatusbarFields(list string) ([]string, error) {
+	var fields []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := statusbarFields[name]; !ok {
+			return nil, fmt.Errorf("unknown status bar field %q (want temp, feels, high, low, wind, dir, humidity or cond)", name)
+		}
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// displayStatusbar prints w as a single line for -format=statusbar, such as
+// "52F 12mph", with no trailing newline.
+func displayStatusbar(out io.Writer, w *weather.CurrentWeather, opts *displayOptions) {
+	parts := make([]string, 0, len(opts.statusbarFields))
+	for _, name := range opts.statusbarFields {
+		parts = append(parts, statusbarFields[name](w, opts.units))
+	}
+	fmt.Fprint(out, strings.Join(parts, " "))
+}
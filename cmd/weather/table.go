@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// table is the header and rows of -format=csv and table output: a row for
+// each current weather, or for each forecast day, starting with the location
+// so several locations' rows can go together.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// tableFormat formats the cells of a table. For csv, measurements are bare
+// numbers in the display units, which the header names, so spreadsheets can
+// read them; otherwise they're formatted as in the text output.
+type tableFormat struct {
+	csv bool
+}
+
+// column returns a column's header, with unit for csv if it's given.
+func (t tableFormat) column(name, unit string) string {
+	if t.csv && unit != "" {
+		return fmt.Sprintf("%s (%s)", name, strings.TrimSpace(unit))
+	}
+	return name
+}
+
+// number formats v, a measurement in the units providers report: for csv
+// converted to the display unit with convert and given precision decimal
+// places, or else with format. It's empty if the provider had no value.
+func (t tableFormat) number(available bool, v float64, convert func(float64) float64, precision int, format func(float64) string) string {
+	switch {
+	case !available:
+		return ""
+	case t.csv:
+		return strconv.FormatFloat(convert(v), 'f', precision, 64)
+	default:
+		return format(v)
+	}
+}
+
+// percent formats a humidity or chance of precipitation.
+func (t tableFormat) percent(v int) string {
+	if t.csv {
+		return strconv.Itoa(v)
+	}
+	return fmt.Sprintf("%d%%", v)
+}
+
+// currentTable returns the table of the current weather at each location.
+func currentTable(opts *displayOptions, csv bool, ws ...*weather.CurrentWeather) table {
+	t := tableFormat{csv}
+	u := opts.units
+	tbl := table{header: []string{
+		"Location",
+		"Observed",
+		"Conditions",
+		t.column("Temperature", u.tempSymbol()),
+		t.column("Feels Like", u.tempSymbol()),
+		t.column("Humidity", "%"),
+		t.column("Wind", u.speedSymbol()),
+		t.column("Wind From", "°"),
+		t.column("Precip Chance", "%"),
+		t.column("Pressure", u.pressure),
+	}}
+	for _, w := range ws {
+		observed, windFrom := "", ""
+		if !w.ObservedAt.IsZero() {
+			observed = w.ObservedAt.Format(opts.timeLayout)
+			if csv {
+				observed = w.ObservedAt.Format(time.RFC3339)
+			}
+		}
+		if csv {
+			windFrom = strconv.Itoa(w.WindDirection)
+		} else if w.WindSpeed > 0 {
+			windFrom = weather.CompassDirection(float64(w.WindDirection))
+		}
+		tbl.rows = append(tbl.rows, []string{
+			w.Location,
+			observed,
+			w.Conditions,
+			t.number(w.Available("temperature"), w.Temperature, u.temp, 1, u.formatTemp),
+			t.number(w.Available("feels_like"), w.FeelsLike, u.temp, 1, u.formatTemp),
+			t.percent(w.Humidity),
+			t.number(w.Available("wind_speed"), w.WindSpeed, u.speed, 1, u.formatSpeed),
+			windFrom,
+			t.percent(w.PrecipProbability),
+			t.number(w.Available("pressure") && w.Pressure > 0, w.Pressure, u.pressureValue, u.pressureDecimals(), u.formatPressure),
+		})
+	}
+	return tbl
+}
+
+// forecastTable returns the table of each forecast's days, leaving out those
+// -filter excludes.
+func forecastTable(opts *displayOptions, csv bool, fs ...*weather.Forecast) table {
+	t := tableFormat{csv}
+	u := opts.units
+	tbl := table{header: []string{
+		"Location",
+		"Date",
+		"Conditions",
+		t.column("High", u.tempSymbol()),
+		t.column("Low", u.tempSymbol()),
+		t.column("Wind", u.speedSymbol()),
+		t.column("Humidity", "%"),
+		t.column("Precip Chance", "%"),
+		t.column("Precip", u.precipitation),
+	}}
+	for _, f := range fs {
+		for _, day := range f.DailyItems {
+			if opts.filter != nil && !opts.filter.Match(day) {
+				continue
+			}
+			tbl.rows = append(tbl.rows, []string{
+				f.Location,
+				day.Date.Format("2006-01-02"),
+				day.Conditions,
+				t.number(day.Available("high"), day.High, u.temp, 1, u.formatTemp),
+				t.number(day.Available("low"), day.Low, u.temp, 1, u.formatTemp),
+				t.number(day.Available("wind_speed"), day.WindSpeed, u.speed, 1, u.formatSpeed),
+				t.percent(day.Humidity),
+				t.percent(day.PrecipProbability),
+				t.number(day.Available("precipitation"), day.Precipitation, u.precip, u.precipDecimals(), u.formatPrecip),
+			})
+		}
+	}
+	return tbl
+}
+
+// writeCSV writes tbl as CSV.
+func writeCSV(out io.Writer, tbl table) error {
+	w := csv.NewWriter(out)
+	w.Write(tbl.header)
+	w.WriteAll(tbl.rows)
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("error writing CSV: %v", err)
+	}
+	return nil
+}
+
+// writeTable writes tbl in aligned columns.
+func writeTable(out io.Writer, tbl table) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, row := range append([][]string{tbl.header}, tbl.rows...) {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// timeLayouts are the time.Format layouts for each -time-format.
+var timeLayouts = map[string]string{
+	"12h": "3:04 PM",
+	"24h": "15:04",
+}
+
+// twelveHourRegions are the countries, by the territory in a locale name
+// such as "en_US.UTF-8", where clocks are usually read in 12-hour time.
+var twelveHourRegions = map[string]bool{
+	"US": true, "CA": true, "AU": true, "NZ": true, "IN": true, "PH": true,
+	"PK": true, "BD": true, "EG": true, "SA": true, "MY": true,
+}
+
+// locale returns the locale for a category such as "LC_TIME", from LC_ALL,
+// the category or LANG, whichever is set first, without any codeset or
+// modifier: "en_US.UTF-8" is "en_US". It's empty if none is set.
+func locale(category string) string {
+	var name string
+	for _, env := range []string{"LC_ALL", category, "LANG"} {
+		if name = os.Getenv(env); name != "" {
+			break
+		}
+	}
+
+	// language_TERRITORY.codeset@modifier
+	name, _, _ = strings.Cut(name, ".")
+	name, _, _ = strings.Cut(name, "@")
+	return name
+}
+
+// localeTimeFormat returns the -time-format to use by default, from the
+// LC_TIME locale. Without one, or with the C locale, it's 12h.
+func localeTimeFormat() string {
+	_, region, ok := strings.Cut(locale("LC_TIME"), "_")
+	if !ok || twelveHourRegions[strings.ToUpper(region)] {
+		return "12h"
+	}
+	return "24h"
+}
+
+// parseTimeFormat returns the time.Format layout for a -time-format value.
+func parseTimeFormat(format string) (string, error) {
+	layout, ok := timeLayouts[strings.ToLower(format)]
+	if !ok {
+		return "", fmt.Errorf("invalid -time-format: %s (want 12h or 24h)", format)
+	}
+	return layout, nil
+}
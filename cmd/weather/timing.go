@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// requestTimings collects the providers' request timings for -verbose.
+type requestTimings struct {
+	mu      sync.Mutex
+	timings []weather.RequestTiming
+}
+
+func (t *requestTimings) record(rt weather.RequestTiming) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timings = append(t.timings, rt)
+}
+
+// print writes the timings to stderr, so they don't mix with JSON output,
+// e.g. "Timing: geocode 120ms, forecast 230ms", followed by the API calls
+// remaining as of the last response that reported it.
+func (t *requestTimings) print() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.timings) == 0 {
+		return
+	}
+
+	parts := make([]string, 0, len(t.timings))
+	var rateLimit *weather.RateLimit
+	for _, rt := range t.timings {
+		if rt.RateLimit != nil {
+			rateLimit = rt.RateLimit
+		}
+		part := fmt.Sprintf("%s %s", rt.Name, rt.Duration.Round(time.Millisecond))
+		if rt.Cached {
+			part += " (cached)"
+		}
+		parts = append(parts, part)
+	}
+	fmt.Fprintf(os.Stderr, "Timing: %s\n", strings.Join(parts, ", "))
+	if rateLimit != nil {
+		fmt.Fprintf(os.Stderr, "API calls remaining: %d\n", rateLimit.Remaining)
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/duluk/weather/pkg/weather"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// units are the display units for each kind of measurement. Providers report
+// imperial values (°F, mph, inHg, inches) which are converted for display.
+//
+//	temperature:   F, C, K
+//	wind:          mph, kph, m/s, knots
+//	pressure:      hPa, inHg
+//	precipitation: in, mm
+//	visibility:    mi, km
+//	snow:          in, cm
+type units struct {
+	// numbers formats the measurements, with the decimal separator and digit
+	// grouping of the display language, e.g. "52,8" in German.
+	numbers       *message.Printer
+	temperature   string
+	wind          string
+	pressure      string
+	precipitation string
+	visibility    string
+	snow          string
+}
+
+var defaultUnits = units{
+	numbers:       message.NewPrinter(language.English),
+	temperature:   "F",
+	wind:          "mph",
+	pressure:      "inHg",
+	precipitation: "in",
+	visibility:    "mi",
+	snow:          "in",
+}
+
+// The valid values for each unit setting, keyed by its config file name.
+var validUnits = map[string][]string{
+	"temperature_unit":   {"F", "C", "K"},
+	"wind_unit":          {"mph", "kph", "m/s", "knots"},
+	"pressure_unit":      {"hPa", "inHg"},
+	"precipitation_unit": {"in", "mm"},
+	"visibility_unit":    {"mi", "km"},
+	"snow_unit":          {"in", "cm"},
+}
+
+// unitPresets are shortcut flags that set several units at once. They are
+// applied in command line order along with the individual unit flags, so
+// e.g. "-si -wind-unit=kph" is metric with wind in km/h.
+var unitPresets = map[string]map[string]string{
+	"-si": {
+		"temperature_unit":   "C",
+		"wind_unit":          "m/s",
+		"pressure_unit":      "hPa",
+		"precipitation_unit": "mm",
+		"visibility_unit":    "km",
+		"snow_unit":          "cm",
+	},
+	"-us": {
+		"temperature_unit":   "F",
+		"wind_unit":          "mph",
+		"pressure_unit":      "inHg",
+		"precipitation_unit": "in",
+		"visibility_unit":    "mi",
+		"snow_unit":          "in",
+	},
+	"-ski": {
+		"temperature_unit": "C",
+		"wind_unit":        "kph",
+		"snow_unit":        "cm",
+	},
+}
+
+// unitPresetNames are the values of a units setting in the config file, for
+// the unitPresets they stand for.
+var unitPresetNames = map[string]string{
+	"metric":   "-si",
+	"si":       "-si",
+	"imperial": "-us",
+	"us":       "-us",
+	"ski":      "-ski",
+}
+
+// set changes the unit for a config key such as "wind_unit". Values are
+// matched case-insensitively.
+func (u *units) set(key, value string) error {
+	valid, ok := validUnits[key]
+	if !ok {
+		return fmt.Errorf("unknown unit setting: %s", key)
+	}
+
+	for _, v := range valid {
+		if strings.EqualFold(v, value) {
+			switch key {
+			case "temperature_unit":
+				u.temperature = v
+			case "wind_unit":
+				u.wind = v
+			case "pressure_unit":
+				u.pressure = v
+			case "precipitation_unit":
+				u.precipitation = v
+			case "visibility_unit":
+				u.visibility = v
+			case "snow_unit":
+				u.snow = v
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid %s: %s (want one of %s)", key, value, strings.Join(valid, ", "))
+}
+
+// temp converts a °F temperature to the display unit.
+func (u units) temp(f float64) float64 {
+	switch u.temperature {
+	case "C":
+		return weather.FtoC(f)
+	case "K":
+		return weather.CtoK(weather.FtoC(f))
+	default:
+		return f
+	}
+}
+
+// fromTemp converts a temperature in the display unit to °F.
+func (u units) fromTemp(t float64) float64 {
+	switch u.temperature {
+	case "C":
+		return weather.CtoF(t)
+	case "K":
+		return weather.CtoF(weather.KtoC(t))
+	default:
+		return t
+	}
+}
+
+func (u units) tempSymbol() string {
+	if u.temperature == "K" {
+		return " K"
+	}
+	return "°" + u.temperature
+}
+
+func (u units) formatTemp(f float64) string {
+	return u.numbers.Sprintf("%.1f%s", u.temp(f), u.tempSymbol())
+}
+
+// tempDelta converts a difference between two °F temperatures to the
+// display unit, where Celsius and kelvin degrees are the same size.
+func (u units) tempDelta(f float64) float64 {
+	if u.temperature == "F" {
+		return f
+	}
+	return f * 5 / 9
+}
+
+func (u units) formatTempDelta(f float64) string {
+	return u.numbers.Sprintf("%.0f%s", u.tempDelta(f), u.tempSymbol())
+}
+
+// speed converts a wind speed in mph to the display unit.
+func (u units) speed(mph float64) float64 {
+	switch u.wind {
+	case "kph":
+		return weather.MphToKph(mph)
+	case "m/s":
+		return weather.MphToMps(mph)
+	case "knots":
+		return weather.MphToKnots(mph)
+	default:
+		return mph
+	}
+}
+
+// fromSpeed converts a wind speed in the display unit to mph.
+func (u units) fromSpeed(v float64) float64 {
+	switch u.wind {
+	case "kph":
+		return weather.KphToMph(v)
+	case "m/s":
+		return weather.MpsToMph(v)
+	case "knots":
+		return weather.KnotsToMph(v)
+	default:
+		return v
+	}
+}
+
+func (u units) speedSymbol() string {
+	if u.wind == "kph" {
+		return "km/h"
+	}
+	return u.wind
+}
+
+func (u units) formatSpeed(mph float64) string {
+	return u.numbers.Sprintf("%.1f %s", u.speed(mph), u.speedSymbol())
+}
+
+// precip converts a precipitation amount in inches to the display unit.
+func (u units) precip(in float64) float64 {
+	if u.precipitation == "mm" {
+		return weather.InchesToMm(in)
+	}
+	return in
+}
+
+// precipDecimals is how many decimal places precipitation amounts are shown
+// with in the display unit.
+func (u units) precipDecimals() int {
+	if u.precipitation == "mm" {
+		return 1
+	}
+	return 2
+}
+
+func (u units) formatPrecip(in float64) string {
+	if u.precipitation == "mm" {
+		return u.numbers.Sprintf("%.1f mm", u.precip(in))
+	}
+	return u.numbers.Sprintf("%.2f in", in)
+}
+
+// pressureValue converts a pressure in inches of mercury to the pressure
+// unit.
+func (u units) pressureValue(inHg float64) float64 {
+	if u.pressure == "hPa" {
+		return weather.InHgToHpa(inHg)
+	}
+	return inHg
+}
+
+// pressureDecimals is how many decimal places pressures are shown with in
+// the pressure unit.
+func (u units) pressureDecimals() int {
+	if u.pressure == "hPa" {
+		return 0
+	}
+	return 2
+}
+
+// formatPressure formats a pressure in inches of mercury in the pressure
+// unit.
+func (u units) formatPressure(inHg float64) string {
+	return u.numbers.Sprintf("%.*f %s", u.pressureDecimals(), u.pressureValue(inHg), u.pressure)
+}
+
+// formatSnow formats a depth of snow in inches in the snow unit.
+func (u units) formatSnow(in float64) string {
+	if u.snow == "cm" {
+		return u.numbers.Sprintf("%.1f cm", weather.InchesToCm(in))
+	}
+	return u.numbers.Sprintf("%.1f in", in)
+}
+
+// formatDistance formats a distance in kilometers in the visibility unit,
+// which is the one used for distances generally.
+func (u units) formatDistance(km float64) string {
+	if u.visibility == "km" {
+		return u.numbers.Sprintf("%.0f km", km)
+	}
+	return u.numbers.Sprintf("%.0f mi", km/1.609344)
+}
+
+// unitNames describes each unit symbol for the -legend output.
+var unitNames = map[string]string{
+	"°F":    "degrees Fahrenheit",
+	"°C":    "degrees Celsius",
+	" K":    "kelvin",
+	"mph":   "miles per hour",
+	"km/h":  "kilometers per hour",
+	"m/s":   "meters per second",
+	"knots": "nautical miles per hour",
+}
+
+// legend returns the symbols used for the selected units and what they mean,
+// in display order.
+func (u units) legend() [][2]string {
+	var entries [][2]string
+	for _, symbol := range []string{u.tempSymbol(), u.speedSymbol()} {
+		entries = append(entries, [2]string{strings.TrimSpace(symbol), unitNames[symbol]})
+	}
+	return append(entries, [2]string{"%", "relative humidity"})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// minWatchInterval keeps -watch from polling faster than providers update
+// (every 15 minutes or so) by enough to matter for rate limits.
+const minWatchInterval = time.Minute
+
+const (
+	ansiClearScreen = "\033[H\033[2J"
+	ansiClearLine   = "\r\033[K"
+)
+
+// watchWeather shows the weather for location, or its forecast, and fetches
+// it again every interval until interrupted. The screen is only redrawn when
+// the weather has changed; otherwise the status line at the bottom says when
+// it was last checked. It only returns for an error that trying again won't
+// help.
+func watchWeather(p weather.Provider, location string, forecast bool, interval time.Duration, opts *displayOptions) error {
+	var current *weather.CurrentWeather
+	var f *weather.Forecast
+	var updated time.Time
+
+	for {
+		now := time.Now()
+		var changed bool
+		var err error
+		if forecast {
+			var latest *weather.Forecast
+			if latest, err = p.GetForecast(location); err == nil {
+				latest.Advise(opts.thresholds, opts.severities)
+				if !latest.Equal(f) {
+					f, changed = latest, true
+				}
+			}
+		} else {
+			var latest *weather.CurrentWeather
+			if latest, err = p.GetCurrentWeather(location); err == nil {
+				latest.Advise(opts.thresholds)
+				if !latest.Equal(current) {
+					current, changed = latest, true
+				}
+			}
+		}
+
+		if errors.Is(err, weather.ErrNotSupported) {
+			// Trying again won't help.
+			return err
+		}
+
+		switch {
+		case err != nil:
+			fmt.Printf("%sError: %v (trying again at %s)", ansiClearLine, err, now.Add(interval).Format(opts.timeLayout))
+		case changed:
+			fmt.Print(ansiClearScreen)
+			if forecast {
+				displayForecast(os.Stdout, f, opts)
+			} else {
+				displayCurrentWeather(os.Stdout, current, opts)
+			}
+			updated = now
+			fmt.Printf("\nUpdated %s", updated.Format(opts.timeLayout))
+		default:
+			fmt.Printf("%sNo change since %s (checked %s)", ansiClearLine,
+				updated.Format(opts.timeLayout), now.Format(opts.timeLayout))
+		}
+
+		time.Sleep(interval)
+	}
+}
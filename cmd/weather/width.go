@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// displayWidth returns the number of terminal columns s occupies: wide and
+// fullwidth runes (CJK, most emoji) take two, combining marks and zero-width
+// characters take none.
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r), unicode.Is(unicode.Cf, r):
+			// combining marks, variation selectors, zero width joiners etc.
+		default:
+			switch width.LookupRune(r).Kind() {
+			case width.EastAsianWide, width.EastAsianFullwidth:
+				n += 2
+			default:
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// padRight pads s with spaces to n display columns. Longer strings are
+// returned unchanged, as with fmt's %-Ns.
+func padRight(s string, n int) string {
+	if w := displayWidth(s); w < n {
+		return s + strings.Repeat(" ", n-w)
+	}
+	return s
+}
@@ -2,4 +2,7 @@ module github.com/duluk/weather
 
 go 1.23.6
 
-require golang.org/x/text v0.22.0
+require (
+	golang.org/x/sync v0.11.0
+	golang.org/x/text v0.22.0
+)
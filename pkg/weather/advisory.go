@@ -0,0 +1,97 @@
+package weather
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Severity int
+
+const (
+	SeverityNone Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	default:
+		return "none"
+	}
+}
+
+// MarshalText encodes s by name, e.g. "high", so JSON output is readable.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Severity) UnmarshalText(text []byte) error {
+	for _, sev := range []Severity{SeverityNone, SeverityLow, SeverityMedium, SeverityHigh} {
+		if strings.EqualFold(string(text), sev.String()) {
+			*s = sev
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown severity %q", text)
+}
+
+// Advisory is a notice about the weather that isn't itself a measurement,
+// such as a warning about dangerous temperatures. CurrentWeather and Forecast
+// collect them in Advisories, which is where every such notice belongs so
+// they can be shown together.
+type Advisory struct {
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// TemperatureThresholds are the apparent temperatures (°F) at or beyond which
+// TemperatureAdvisories warns about extreme heat or cold.
+type TemperatureThresholds struct {
+	Heat float64
+	Cold float64
+}
+
+// DefaultTemperatureThresholds are roughly where the US National Weather
+// Service heat index reaches "danger" and wind chill becomes a frostbite risk.
+var DefaultTemperatureThresholds = TemperatureThresholds{Heat: 105, Cold: 0}
+
+// TemperatureAdvisories warns when the feels-like temperature in w is at or
+// beyond the thresholds.
+func TemperatureAdvisories(w *CurrentWeather, t TemperatureThresholds) []Advisory {
+	switch {
+	case w.FeelsLike >= t.Heat:
+		return []Advisory{{
+			Severity: SeverityHigh,
+			Message:  fmt.Sprintf("Extreme heat (feels like %.0f°F) — limit outdoor activity and stay hydrated", w.FeelsLike),
+		}}
+	case w.FeelsLike <= t.Cold:
+		return []Advisory{{
+			Severity: SeverityHigh,
+			Message:  fmt.Sprintf("Extreme cold (feels like %.0f°F) — frostbite risk, cover exposed skin", w.FeelsLike),
+		}}
+	}
+	return nil
+}
+
+// Advise adds the advisories for w, with t for the temperature warnings, to
+// w.Advisories.
+func (w *CurrentWeather) Advise(t TemperatureThresholds) {
+	w.Advisories = append(w.Advisories, TemperatureAdvisories(w, t)...)
+}
+
+// Advise adds the advisories for the current weather, if any, to its
+// Advisories, and those for the days ahead, with s for how severe their
+// conditions are, to f.Advisories.
+func (f *Forecast) Advise(t TemperatureThresholds, s ConditionSeverities) {
+	if f.Current != nil {
+		f.Current.Advise(t)
+	}
+	f.Advisories = append(f.Advisories, ConditionAdvisories(f, s)...)
+}
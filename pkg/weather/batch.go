@@ -0,0 +1,80 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// BatchConcurrency is the most requests FetchBatch has in flight at once, to
+// stay clear of provider rate limits.
+const BatchConcurrency = 4
+
+// BatchResult is the outcome of fetching one location in a batch. Exactly one
+// of Current (or Forecast, if requested) and Err is set.
+type BatchResult struct {
+	Location string
+	Current  *CurrentWeather
+	Forecast *Forecast
+	Err      error
+}
+
+// FetchBatch fetches the current weather for each location, or the forecast
+// if forecast is set, concurrently. Results are in the same order as
+// locations, and a failure for one location doesn't affect the others. The
+// error joins those of every failed location, each prefixed with the location.
+func FetchBatch(p Provider, locations []string, forecast bool) ([]BatchResult, error) {
+	results := make([]BatchResult, len(locations))
+	fetchBatch(p, locations, forecast, func(i int, r BatchResult) {
+		results[i] = r
+	})
+
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Location, r.Err))
+		}
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// StreamBatch is like FetchBatch, but sends each result on the returned
+// channel as soon as it is ready, so in no particular order. The channel is
+// closed once every location has been fetched.
+func StreamBatch(p Provider, locations []string, forecast bool) <-chan BatchResult {
+	ch := make(chan BatchResult)
+	go func() {
+		fetchBatch(p, locations, forecast, func(_ int, r BatchResult) {
+			ch <- r
+		})
+		close(ch)
+	}()
+	return ch
+}
+
+// fetchBatch fetches every location, at most BatchConcurrency at a time, and
+// calls done with the index and result of each as it finishes. done may be
+// called from several goroutines at once.
+func fetchBatch(p Provider, locations []string, forecast bool, done func(int, BatchResult)) {
+	sem := make(chan struct{}, BatchConcurrency)
+
+	var wg sync.WaitGroup
+	for i, location := range locations {
+		wg.Add(1)
+		go func(i int, location string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			r := BatchResult{Location: location}
+			if forecast {
+				r.Forecast, r.Err = p.GetForecast(location)
+			} else {
+				r.Current, r.Err = p.GetCurrentWeather(location)
+			}
+			done(i, r)
+		}(i, location)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,41 @@
+package weather
+
+import "math"
+
+// idealHigh is the daily high (°F) BestDay considers most pleasant.
+const idealHigh = 72
+
+// conditionPenalty is how much each condition counts against a day in
+// BestDay, in the same units as a degree away from idealHigh.
+var conditionPenalty = map[Condition]float64{
+	ConditionClear:        0,
+	ConditionPartlyCloudy: 2,
+	ConditionCloudy:       5,
+	ConditionUnknown:      5,
+	ConditionFog:          6,
+	ConditionHaze:         6,
+	ConditionDrizzle:      10,
+	ConditionRain:         15,
+	ConditionSnow:         15,
+	ConditionSleet:        20,
+	ConditionFreezingRain: 20,
+	ConditionThunderstorm: 25,
+}
+
+// BestDay returns the most pleasant day of the forecast: the one with the
+// high nearest idealHigh, the least chance of precipitation, the lightest
+// wind and the fairest conditions, all weighed together. ok is false if the
+// forecast has no days.
+func (f *Forecast) BestDay() (day DailyForecast, ok bool) {
+	best := math.Inf(-1)
+	for _, d := range f.DailyItems {
+		score := -math.Abs(d.High-idealHigh) -
+			float64(d.PrecipProbability)/5 -
+			d.WindSpeed/2 -
+			conditionPenalty[d.Condition]
+		if score > best {
+			best, day, ok = score, d, true
+		}
+	}
+	return day, ok
+}
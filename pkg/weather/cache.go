@@ -0,0 +1,149 @@
+package weather
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache stores raw API responses keyed by request. Implementations must be
+// safe for concurrent use and should treat entries older than their ttl as
+// missing. Library users can supply their own (e.g. backed by Redis).
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// cacheEntry wraps a response so that a cache hit can report when it was
+// originally fetched, whatever the Cache implementation.
+type cacheEntry struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// GetCached returns the response stored under key by SetCached and the time
+// it was fetched.
+func GetCached(c Cache, key string) ([]byte, time.Time, bool) {
+	data, ok := c.Get(key)
+	if !ok {
+		return nil, time.Time{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, time.Time{}, false
+	}
+	return entry.Body, entry.FetchedAt, true
+}
+
+// SetCached stores a JSON response body under key for ttl.
+func SetCached(c Cache, key string, body []byte, ttl time.Duration) {
+	data, err := json.Marshal(cacheEntry{FetchedAt: time.Now(), Body: body})
+	if err != nil {
+		return
+	}
+	c.Set(key, data, ttl)
+}
+
+// FileCache is a Cache that keeps one file per key in Dir. Write errors are
+// ignored; a failed write is just a future cache miss.
+type FileCache struct {
+	Dir string
+}
+
+type fileCacheEntry struct {
+	Expires time.Time `json:"expires"`
+	Value   []byte    `json:"value"`
+}
+
+func NewFileCache(dir string) *FileCache {
+	return &FileCache{Dir: dir}
+}
+
+func (c *FileCache) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry fileCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || time.Now().After(entry.Expires) {
+		return nil, false
+	}
+	return entry.Value, true
+}
+
+func (c *FileCache) Set(key string, value []byte, ttl time.Duration) {
+	data, err := json.Marshal(fileCacheEntry{Expires: time.Now().Add(ttl), Value: value})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return
+	}
+
+	// Write to a temporary file and rename it into place so that concurrent
+	// readers never see a partial entry.
+	tmp, err := os.CreateTemp(c.Dir, "tmp-*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	os.Rename(tmp.Name(), c.path(key))
+}
+
+// The key is usually a request URL (which may contain an API key), so hash it
+// rather than using it in a file name directly.
+func (c *FileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// MemoryCache is a Cache held in process memory, for long-running services.
+// Expired entries are dropped when they are next read.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	expires time.Time
+	value   []byte
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryCacheEntry)}
+}
+
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = memoryCacheEntry{expires: time.Now().Add(ttl), value: value}
+}
@@ -0,0 +1,73 @@
+package weather
+
+import "strings"
+
+// Capability is a set of optional features a provider supports, beyond the
+// current weather and forecast every Provider has.
+type Capability uint
+
+const (
+	// CapExtendedForecast is a forecast of more than the usual five days.
+	CapExtendedForecast Capability = 1 << iota
+	// CapHourly is an hour by hour forecast.
+	CapHourly
+	// CapAlerts is official weather warnings for the location.
+	CapAlerts
+	// CapAirQuality is air quality and pollution data.
+	CapAirQuality
+	// CapLanguage is weather descriptions in languages other than English.
+	CapLanguage
+	// CapReverseGeocoding is looking up place names for coordinates.
+	CapReverseGeocoding
+	// CapClimateNormals is long-term averages, through NormalsProvider.
+	CapClimateNormals
+	// CapWeekForecast is a forecast of at least seven days, as configured.
+	CapWeekForecast
+)
+
+var capabilityNames = []struct {
+	cap  Capability
+	name string
+}{
+	{CapExtendedForecast, "extended forecast"},
+	{CapHourly, "hourly forecast"},
+	{CapAlerts, "alerts"},
+	{CapAirQuality, "air quality"},
+	{CapLanguage, "languages"},
+	{CapReverseGeocoding, "reverse geocoding"},
+	{CapClimateNormals, "climate normals"},
+	{CapWeekForecast, "seven-day forecast"},
+}
+
+// Has reports whether c includes every capability in want.
+func (c Capability) Has(want Capability) bool {
+	return c&want == want
+}
+
+func (c Capability) String() string {
+	var names []string
+	for _, n := range capabilityNames {
+		if c.Has(n.cap) {
+			names = append(names, n.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// CapabilityReporter is implemented by providers that support any optional
+// features, so callers can check before relying on one.
+type CapabilityReporter interface {
+	Capabilities() Capability
+}
+
+// CapabilitiesOf returns the optional features p supports, none if it doesn't
+// report them.
+func CapabilitiesOf(p Provider) Capability {
+	if r, ok := p.(CapabilityReporter); ok {
+		return r.Capabilities()
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+package weather
+
+import "time"
+
+// ClimateNormal is the long-term average weather for a place on a day of the
+// year.
+type ClimateNormal struct {
+	Date time.Time `json:"date"`
+	// High and Low are the average daily high and low (°F).
+	High float64 `json:"high"`
+	Low  float64 `json:"low"`
+	// FirstYear and LastYear are the period averaged over.
+	FirstYear int `json:"first_year"`
+	LastYear  int `json:"last_year"`
+	// History is the weather on the same month and day in each year of the
+	// period that has data, for HighRecord and LowRecord.
+	History []PastDay `json:"history,omitempty"`
+}
+
+// NormalsProvider is implemented by providers that can look up climate
+// normals, as reported by CapClimateNormals.
+type NormalsProvider interface {
+	ClimateNormal(location string, date time.Time) (*ClimateNormal, error)
+}
+
+// TemperatureAnomaly returns how far an observed temperature is from the
+// normal one, positive when above normal and negative when below, in the
+// same unit as both.
+func TemperatureAnomaly(observed, normal float64) float64 {
+	return observed - normal
+}
+
+// PastDay is the high and low (°F) on a date in a past year.
+type PastDay struct {
+	Year int     `json:"year"`
+	High float64 `json:"high"`
+	Low  float64 `json:"low"`
+}
+
+// Record is how a temperature ranks against the same date in past years.
+type Record int
+
+const (
+	// NoRecord is a temperature some past year beat.
+	NoRecord Record = iota
+	// RecordTied equals the record but doesn't beat it.
+	RecordTied
+	// RecordSet beats every past year.
+	RecordSet
+)
+
+// HighRecord reports whether high would be the warmest high of the date in
+// history, the same date's weather in past years. A high equal to the warmest
+// ties the record rather than setting it. Empty history is NoRecord.
+func HighRecord(high float64, history []PastDay) Record {
+	return record(high, history, func(d PastDay) float64 { return d.High }, 1)
+}
+
+// LowRecord is HighRecord for the coldest low.
+func LowRecord(low float64, history []PastDay) Record {
+	return record(low, history, func(d PastDay) float64 { return d.Low }, -1)
+}
+
+// record ranks temp against the values of history, where sign is 1 if
+// higher is the record and -1 if lower is.
+func record(temp float64, history []PastDay, value func(PastDay) float64, sign float64) Record {
+	if len(history) == 0 {
+		return NoRecord
+	}
+	result := RecordSet
+	for _, d := range history {
+		switch past := value(d); {
+		case sign*past > sign*temp:
+			return NoRecord
+		case past == temp:
+			result = RecordTied
+		}
+	}
+	return result
+}
@@ -0,0 +1,49 @@
+package weather
+
+import "strings"
+
+// Thresholds for ClothingHint. Temperatures are feels-like °F, the chance of
+// precipitation is in percent and wind speed is in mph.
+const (
+	// Below this, it's winter coat, hat and gloves weather.
+	clothingFreezing = 32
+	// Below this, a warm coat.
+	clothingCold = 50
+	// Below this, a light jacket or sweater.
+	clothingCool = 65
+	// At or above this, shorts; between clothingCool and here, a t-shirt.
+	clothingWarm = 80
+	// At or above this chance of precipitation, bring an umbrella.
+	clothingUmbrellaChance = 40
+	// At or above this wind speed, a windbreaker is worth it even when it's
+	// not cold enough for a coat.
+	clothingWindy = 20
+)
+
+// ClothingHint suggests what to wear for a feels-like temperature (°F), a
+// chance of precipitation (percent) and a wind speed (mph), e.g. "Bring a
+// jacket; umbrella recommended".
+func ClothingHint(feelsLike float64, precipProbability int, windSpeed float64) string {
+	var hint string
+	switch {
+	case feelsLike < clothingFreezing:
+		hint = "Bundle up: heavy coat, hat and gloves"
+	case feelsLike < clothingCold:
+		hint = "Wear a warm coat"
+	case feelsLike < clothingCool:
+		hint = "Bring a jacket"
+	case feelsLike < clothingWarm:
+		hint = "T-shirt weather"
+	default:
+		hint = "Shorts weather"
+	}
+
+	parts := []string{hint}
+	if windSpeed >= clothingWindy && feelsLike >= clothingCold {
+		parts = append(parts, "windbreaker for the wind")
+	}
+	if precipProbability >= clothingUmbrellaChance {
+		parts = append(parts, "umbrella recommended")
+	}
+	return strings.Join(parts, "; ")
+}
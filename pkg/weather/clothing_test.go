@@ -0,0 +1,32 @@
+package weather
+
+import "testing"
+
+func TestClothingHint(t *testing.T) {
+	tests := []struct {
+		feelsLike float64
+		chance    int
+		wind      float64
+		want      string
+	}{
+		{20, 0, 0, "Bundle up: heavy coat, hat and gloves"},
+		{32, 0, 0, "Wear a warm coat"},
+		{50, 0, 0, "Bring a jacket"},
+		{65, 0, 0, "T-shirt weather"},
+		{80, 0, 0, "Shorts weather"},
+		{70, 40, 0, "T-shirt weather; umbrella recommended"},
+		{70, 39, 0, "T-shirt weather"},
+		// The wind threshold is 20 mph: 19.9 mph, or 32 km/h, isn't
+		// windbreaker weather.
+		{70, 0, 19.9, "T-shirt weather"},
+		{70, 0, 20, "T-shirt weather; windbreaker for the wind"},
+		{60, 50, 25, "Bring a jacket; windbreaker for the wind; umbrella recommended"},
+		// A warm coat is enough for the wind when it's cold.
+		{40, 0, 30, "Wear a warm coat"},
+	}
+	for _, tt := range tests {
+		if got := ClothingHint(tt.feelsLike, tt.chance, tt.wind); got != tt.want {
+			t.Errorf("ClothingHint(%v, %d, %v) = %q, want %q", tt.feelsLike, tt.chance, tt.wind, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package weather
+
+import "strings"
+
+// Condition is a provider-agnostic classification of the weather, derived
+// from the provider's own weather code.
+type Condition int
+
+const (
+	ConditionUnknown Condition = iota
+	ConditionClear
+	ConditionPartlyCloudy
+	ConditionCloudy
+	ConditionFog
+	ConditionHaze
+	ConditionDrizzle
+	ConditionRain
+	ConditionFreezingRain
+	ConditionSleet
+	ConditionSnow
+	ConditionThunderstorm
+)
+
+var conditionNames = map[Condition]string{
+	ConditionUnknown:      "unknown",
+	ConditionClear:        "clear",
+	ConditionPartlyCloudy: "partly cloudy",
+	ConditionCloudy:       "cloudy",
+	ConditionFog:          "fog",
+	ConditionHaze:         "haze",
+	ConditionDrizzle:      "drizzle",
+	ConditionRain:         "rain",
+	ConditionFreezingRain: "freezing rain",
+	ConditionSleet:        "sleet",
+	ConditionSnow:         "snow",
+	ConditionThunderstorm: "thunderstorm",
+}
+
+func (c Condition) String() string {
+	if name, ok := conditionNames[c]; ok {
+		return name
+	}
+	return conditionNames[ConditionUnknown]
+}
+
+// ParseCondition returns the condition named name, such as "freezing rain"
+// or "freezing_rain", ignoring case. ok is false if there is none.
+func ParseCondition(name string) (c Condition, ok bool) {
+	name = strings.ReplaceAll(strings.TrimSpace(name), "_", " ")
+	for c, n := range conditionNames {
+		if strings.EqualFold(name, n) {
+			return c, true
+		}
+	}
+	return ConditionUnknown, false
+}
+
+// MarshalText makes conditions appear by name in JSON output.
+func (c Condition) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// Precipitating reports whether c is a condition with something falling:
+// drizzle, rain, sleet, snow or a thunderstorm, including showers of any.
+func (c Condition) Precipitating() bool {
+	switch c {
+	case ConditionDrizzle, ConditionRain, ConditionFreezingRain, ConditionSleet,
+		ConditionSnow, ConditionThunderstorm:
+		return true
+	}
+	return false
+}
+
+// IsPrecipitating reports whether it's raining, snowing or otherwise
+// precipitating in w.
+func IsPrecipitating(w *CurrentWeather) bool {
+	return w.Condition.Precipitating()
+}
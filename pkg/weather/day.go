@@ -0,0 +1,120 @@
+package weather
+
+import "time"
+
+// Day returns the forecast's entry for the next weekday, counting the
+// location's current day, so Day(time.Saturday) on a Saturday is today's, as
+// Today returns it. The entry is the forecast's own, not a copy, except for a
+// today Today made up. ok is false if that day is beyond the forecast.
+func (f *Forecast) Day(weekday time.Weekday) (*DailyForecast, bool) {
+	return f.weekday(weekday, time.Now())
+}
+
+// Today returns the forecast's entry for the location's current day,
+// whatever the provider. Some include today in DailyItems, and that entry is
+// returned. Others (Open-Meteo) leave it out, and it's made from Current
+// instead: today's high and low, chance of precipitation, conditions, wind
+// and humidity. The made-up entry has no precipitation total, and any of the
+// high, low and wind speed that Current hadn't are listed as unavailable. ok
+// is false if neither is available, including when Current has no high or
+// low.
+func (f *Forecast) Today() (*DailyForecast, bool) {
+	return f.today(time.Now())
+}
+
+// Tomorrow returns the forecast's entry for the day after the location's
+// current day. ok is false if the forecast doesn't reach it.
+func (f *Forecast) Tomorrow() (*DailyForecast, bool) {
+	return f.onDate(f.localTime(time.Now()).AddDate(0, 0, 1))
+}
+
+// onDate returns the forecast's entry for date's calendar day, in date's time
+// zone. ok is false if the forecast doesn't cover it.
+func (f *Forecast) onDate(date time.Time) (*DailyForecast, bool) {
+	y, m, d := date.Date()
+	for i, day := range f.DailyItems {
+		// Dates are midnight UTC of the location's day.
+		if dy, dm, dd := day.Date.UTC().Date(); dy == y && dm == m && dd == d {
+			return &f.DailyItems[i], true
+		}
+	}
+	return nil, false
+}
+
+// today is Today as of now.
+func (f *Forecast) today(now time.Time) (*DailyForecast, bool) {
+	now = f.localTime(now)
+	if day, ok := f.onDate(now); ok {
+		return day, true
+	}
+
+	w := f.Current
+	if w == nil || (w.TempMax == 0 && w.TempMin == 0) {
+		return nil, false
+	}
+	y, m, d := now.Date()
+	return &DailyForecast{
+		Date:              time.Date(y, m, d, 0, 0, 0, 0, time.UTC),
+		Conditions:        w.Conditions,
+		WeatherCode:       w.WeatherCode,
+		Condition:         w.Condition,
+		PrecipType:        DetectPrecipType(w.Condition, w.TempMax),
+		High:              w.TempMax,
+		Low:               w.TempMin,
+		WindSpeed:         w.WindSpeed,
+		WindDirection:     w.WindDirection,
+		Humidity:          w.Humidity,
+		PrecipProbability: w.PrecipProbability,
+		Unavailable:       todayUnavailable(w),
+	}, true
+}
+
+// todayUnavailable returns the measurements of the day Today makes from w
+// that w didn't have.
+func todayUnavailable(w *CurrentWeather) []string {
+	var unavailable []string
+	for _, m := range []struct{ current, day string }{
+		{"temp_max", "high"},
+		{"temp_min", "low"},
+		{"wind_speed", "wind_speed"},
+	} {
+		if !w.Available(m.current) {
+			unavailable = append(unavailable, m.day)
+		}
+	}
+	return unavailable
+}
+
+// FromTomorrow returns the forecast's entries after the location's current
+// day, leaving out any for today or before, which the current conditions
+// cover.
+func (f *Forecast) FromTomorrow(now time.Time) []DailyForecast {
+	y, m, d := f.localTime(now).Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	for i, day := range f.DailyItems {
+		// Dates are midnight UTC of the location's day, as for onDate.
+		if day.Date.UTC().After(today) {
+			return f.DailyItems[i:]
+		}
+	}
+	return nil
+}
+
+// weekday is Day as of now.
+func (f *Forecast) weekday(wd time.Weekday, now time.Time) (*DailyForecast, bool) {
+	now = f.localTime(now)
+	days := (int(wd) - int(now.Weekday()) + 7) % 7
+	if days == 0 {
+		return f.today(now)
+	}
+	return f.onDate(now.AddDate(0, 0, days))
+}
+
+// localTime returns now in the location's time zone, when the forecast says
+// what that is, since the location's date can differ from ours.
+func (f *Forecast) localTime(now time.Time) time.Time {
+	if w := f.Current; w != nil && !w.Sunrise.IsZero() {
+		return now.In(w.Sunrise.Location())
+	}
+	return now
+}
@@ -0,0 +1,68 @@
+package weather
+
+import (
+	"testing"
+	"time"
+)
+
+// week returns a forecast of the days from 2025-03-02, a Sunday, with highs
+// counting up from 50, and the current weather as Open-Meteo gives it, with
+// today's high and low but no entry for today.
+func week(days int) *Forecast {
+	f := &Forecast{Current: &CurrentWeather{Conditions: "clear sky", TempMax: 48, TempMin: 30}}
+	for i := range days {
+		f.DailyItems = append(f.DailyItems, DailyForecast{
+			Date: time.Date(2025, 3, 2+i, 0, 0, 0, 0, time.UTC),
+			High: float64(50 + i),
+		})
+	}
+	return f
+}
+
+// Saturday 2025-03-01, the day before the forecast's first.
+var saturday = time.Date(2025, 3, 1, 15, 0, 0, 0, time.UTC)
+
+func TestWeekday(t *testing.T) {
+	tests := []struct {
+		wd       time.Weekday
+		wantHigh float64
+		wantOK   bool
+	}{
+		// Today's is made from Current.
+		{time.Saturday, 48, true},
+		{time.Sunday, 50, true},
+		{time.Wednesday, 53, true},
+		// Friday is beyond the five days.
+		{time.Friday, 0, false},
+	}
+	for _, tt := range tests {
+		day, ok := week(5).weekday(tt.wd, saturday)
+		if ok != tt.wantOK || (ok && day.High != tt.wantHigh) {
+			t.Errorf("weekday(%s) = %+v, %v, want a high of %v, %v", tt.wd, day, ok, tt.wantHigh, tt.wantOK)
+		}
+	}
+}
+
+func TestToday(t *testing.T) {
+	// A provider that includes today in DailyItems has it returned, not a
+	// copy.
+	f := week(3)
+	day, ok := f.today(saturday.AddDate(0, 0, 1))
+	if !ok || day != &f.DailyItems[0] {
+		t.Errorf("today = %+v, %v, want the first entry", day, ok)
+	}
+
+	day, ok = week(3).today(saturday)
+	if !ok || day.High != 48 || day.Low != 30 || day.Conditions != "clear sky" {
+		t.Errorf("today = %+v, %v, want one made from Current", day, ok)
+	}
+	if !day.Date.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("today's Date = %s, want midnight UTC of 2025-03-01", day.Date)
+	}
+
+	f = week(3)
+	f.Current = nil
+	if day, ok := f.today(saturday); ok {
+		t.Errorf("today without Current = %+v, want none", day)
+	}
+}
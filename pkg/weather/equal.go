@@ -0,0 +1,56 @@
+package weather
+
+import (
+	"reflect"
+	"slices"
+	"time"
+)
+
+// Equal reports whether w and o describe the same weather. When each was
+// cached is ignored, so a cached copy equals the fresh response it came from.
+func (w *CurrentWeather) Equal(o *CurrentWeather) bool {
+	if w == nil || o == nil {
+		return w == o
+	}
+	if !w.Sunrise.Equal(o.Sunrise) || !w.Sunset.Equal(o.Sunset) || !w.ObservedAt.Equal(o.ObservedAt) ||
+		!slices.Equal(w.Unavailable, o.Unavailable) || !slices.Equal(w.Advisories, o.Advisories) ||
+		!slices.EqualFunc(w.Hourly, o.Hourly, func(a, b HourlyPrecip) bool {
+			return a.Time.Equal(b.Time) && a.Precipitation == b.Precipitation && a.Probability == b.Probability
+		}) {
+		return false
+	}
+
+	// The fields compared above can't be with DeepEqual, which would also
+	// compare the times' zones and tell nil and empty lists apart.
+	a, b := *w, *o
+	for _, c := range []*CurrentWeather{&a, &b} {
+		c.Sunrise, c.Sunset, c.ObservedAt, c.CachedAt = time.Time{}, time.Time{}, time.Time{}, time.Time{}
+		c.Unavailable, c.Advisories, c.Hourly = nil, nil, nil
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Equal reports whether f and o are the same forecast, ignoring when each
+// was cached as CurrentWeather.Equal does.
+func (f *Forecast) Equal(o *Forecast) bool {
+	if f == nil || o == nil {
+		return f == o
+	}
+	if f.Location != o.Location || !f.Current.Equal(o.Current) || len(f.DailyItems) != len(o.DailyItems) ||
+		!slices.Equal(f.Advisories, o.Advisories) {
+		return false
+	}
+
+	for i := range f.DailyItems {
+		a, b := f.DailyItems[i], o.DailyItems[i]
+		if !a.Date.Equal(b.Date) || !slices.Equal(a.Unavailable, b.Unavailable) {
+			return false
+		}
+		a.Date, b.Date = time.Time{}, time.Time{}
+		a.Unavailable, b.Unavailable = nil, nil
+		if !reflect.DeepEqual(a, b) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,140 @@
+package weather
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DayFilter is a set of conditions that a DailyForecast must all satisfy.
+// See ParseDayFilter for the syntax.
+type DayFilter []dayCondition
+
+type dayCondition struct {
+	field string
+	op    string
+	value float64
+}
+
+// dayFilterField is a filterable field: the value it reads from a
+// DailyForecast and what that's a measure of, which decides how Convert
+// converts its thresholds.
+type dayFilterField struct {
+	value func(DailyForecast) float64
+	kind  fieldKind
+}
+
+type fieldKind int
+
+const (
+	kindPercent fieldKind = iota
+	kindTemperature
+	kindSpeed
+)
+
+var dayFilterFields = map[string]dayFilterField{
+	"high":     {func(d DailyForecast) float64 { return d.High }, kindTemperature},
+	"low":      {func(d DailyForecast) float64 { return d.Low }, kindTemperature},
+	"wind":     {func(d DailyForecast) float64 { return d.WindSpeed }, kindSpeed},
+	"humidity": {func(d DailyForecast) float64 { return float64(d.Humidity) }, kindPercent},
+	"precip":   {func(d DailyForecast) float64 { return float64(d.PrecipProbability) }, kindPercent},
+}
+
+// Longer operators come first so that ">=" isn't read as ">" followed by "=".
+var dayFilterOps = []string{">=", "<=", "!=", ">", "<", "="}
+
+// ParseDayFilter parses a comma-separated list of conditions such as
+// "high>70,precip<30". Each condition is a field (high, low, wind, humidity
+// or precip, the chance of precipitation), a comparator (<, <=, >, >=, = or
+// !=) and a number. Temperatures are compared in °F and wind speeds in mph,
+// unless the filter is converted with Convert.
+func ParseDayFilter(expr string) (DayFilter, error) {
+	var filter DayFilter
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		cond, err := parseDayCondition(part)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter, cond)
+	}
+
+	if len(filter) == 0 {
+		return nil, fmt.Errorf("empty filter")
+	}
+	return filter, nil
+}
+
+func parseDayCondition(s string) (dayCondition, error) {
+	for _, op := range dayFilterOps {
+		idx := strings.Index(s, op)
+		if idx < 0 {
+			continue
+		}
+
+		field := strings.ToLower(strings.TrimSpace(s[:idx]))
+		if _, ok := dayFilterFields[field]; !ok {
+			return dayCondition{}, fmt.Errorf("unknown filter field %q in %q", field, s)
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(s[idx+len(op):]), 64)
+		if err != nil {
+			return dayCondition{}, fmt.Errorf("invalid filter value in %q", s)
+		}
+
+		return dayCondition{field: field, op: op, value: value}, nil
+	}
+
+	return dayCondition{}, fmt.Errorf("no comparator in filter condition %q", s)
+}
+
+// Match reports whether day satisfies every condition in the filter.
+func (f DayFilter) Match(day DailyForecast) bool {
+	for _, cond := range f {
+		if !cond.match(dayFilterFields[cond.field].value(day)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Convert returns the filter with its temperature thresholds converted to °F
+// with temp and its wind speed thresholds to mph with speed, for a filter
+// written in other units. Percentages are left as they are.
+func (f DayFilter) Convert(temp, speed func(float64) float64) DayFilter {
+	if f == nil {
+		return nil
+	}
+	converted := make(DayFilter, len(f))
+	for i, cond := range f {
+		switch dayFilterFields[cond.field].kind {
+		case kindTemperature:
+			cond.value = temp(cond.value)
+		case kindSpeed:
+			cond.value = speed(cond.value)
+		}
+		converted[i] = cond
+	}
+	return converted
+}
+
+func (c dayCondition) match(v float64) bool {
+	switch c.op {
+	case ">=":
+		return v >= c.value
+	case "<=":
+		return v <= c.value
+	case "!=":
+		return v != c.value
+	case ">":
+		return v > c.value
+	case "<":
+		return v < c.value
+	default:
+		return v == c.value
+	}
+}
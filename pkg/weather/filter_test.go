@@ -0,0 +1,77 @@
+package weather
+
+import (
+	"testing"
+)
+
+func TestDayFilterMatch(t *testing.T) {
+	day := DailyForecast{High: 72, Low: 55, WindSpeed: 12, Humidity: 60, PrecipProbability: 30}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"high>70", true},
+		{"high>72", false},
+		{"high>=72", true},
+		{"low<55", false},
+		{"low<=55", true},
+		{"wind=12", true},
+		{"wind!=12", false},
+		{"humidity<60", false},
+		{"precip<30", false},
+		{"precip<=30", true},
+		{"high>70,precip<50", true},
+		{"high>70,precip<30", false},
+		{" HIGH > 70 , ,precip < 50 ", true},
+	}
+	for _, tt := range tests {
+		f, err := ParseDayFilter(tt.expr)
+		if err != nil {
+			t.Errorf("ParseDayFilter(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := f.Match(day); got != tt.want {
+			t.Errorf("ParseDayFilter(%q).Match = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseDayFilterErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		" , ",
+		"rain>3",
+		"high",
+		"high>warm",
+		"high>70,low",
+	} {
+		if _, err := ParseDayFilter(expr); err == nil {
+			t.Errorf("ParseDayFilter(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+// A filter in metric units matches the day its thresholds convert to, and
+// percentages aren't converted.
+func TestDayFilterConvert(t *testing.T) {
+	day := DailyForecast{High: 77, WindSpeed: 20, PrecipProbability: 30}
+	f, err := ParseDayFilter("high<26,wind>30,precip<40")
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted := f.Convert(CtoF, KphToMph)
+
+	// 26°C is 78.8°F and 30 km/h is 18.6 mph.
+	if !converted.Match(day) {
+		t.Errorf("converted filter %v doesn't match %+v", converted, day)
+	}
+	if f.Match(day) {
+		t.Errorf("unconverted filter %v matches %+v, comparing °C with °F", f, day)
+	}
+	if converted[2].value != 40 {
+		t.Errorf("precip threshold = %v, want 40 unconverted", converted[2].value)
+	}
+	if DayFilter(nil).Convert(CtoF, KphToMph) != nil {
+		t.Error("converting no filter made one")
+	}
+}
@@ -0,0 +1,61 @@
+package weather
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Fixtures keeps raw API responses in files, to record them from live
+// requests and replay them later without the network. Each file is named by
+// provider and endpoint, e.g. openmeteo.geocode.json or
+// openweather.forecast.daily.json, so there is one response per endpoint:
+// the last recorded.
+type Fixtures struct {
+	// Dir is the directory the files are in; empty is the current one.
+	Dir string
+	// Record writes each response fetched to its file. Otherwise responses
+	// are read from the files instead of being fetched.
+	Record bool
+}
+
+// Replaying reports whether responses are to be read from the files. A nil
+// *Fixtures neither replays nor records.
+func (f *Fixtures) Replaying() bool {
+	return f != nil && !f.Record
+}
+
+// Recording reports whether responses are to be written to the files.
+func (f *Fixtures) Recording() bool {
+	return f != nil && f.Record
+}
+
+// Path returns the file for provider's endpoint, in which any slashes
+// become dots.
+func (f *Fixtures) Path(provider, endpoint string) string {
+	return filepath.Join(f.Dir, provider+"."+strings.ReplaceAll(endpoint, "/", ".")+".json")
+}
+
+// Load returns the response recorded for provider's endpoint.
+func (f *Fixtures) Load(provider, endpoint string) ([]byte, error) {
+	body, err := os.ReadFile(f.Path(provider, endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("error reading test file: %v", err)
+	}
+	return body, nil
+}
+
+// Save records body as the response for provider's endpoint, replacing any
+// recorded before.
+func (f *Fixtures) Save(provider, endpoint string, body []byte) error {
+	if f.Dir != "" {
+		if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+			return fmt.Errorf("error creating test data directory: %v", err)
+		}
+	}
+	if err := os.WriteFile(f.Path(provider, endpoint), body, 0o644); err != nil {
+		return fmt.Errorf("error writing test file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package weather
+
+// GroupSummary is the spread of current temperatures across a group of
+// locations, such as the cities of a metro area.
+type GroupSummary struct {
+	// Locations is how many locations are in the group, and Reporting how
+	// many of them had a temperature.
+	Locations int `json:"locations"`
+	Reporting int `json:"reporting"`
+	// Coldest and Warmest are the locations, as named by the provider,
+	// with the lowest and highest temperatures.
+	Coldest string  `json:"coldest"`
+	Warmest string  `json:"warmest"`
+	MinTemp float64 `json:"min_temp"`
+	MaxTemp float64 `json:"max_temp"`
+	AvgTemp float64 `json:"avg_temp"`
+}
+
+// SummarizeGroup returns the minimum, maximum and average current
+// temperature of the batch results, which are for the current weather. Failed
+// locations and ones with no temperature are left out. ok is false if none
+// had one.
+func SummarizeGroup(results []BatchResult) (s GroupSummary, ok bool) {
+	s.Locations = len(results)
+	var sum float64
+	for _, r := range results {
+		if r.Err != nil || r.Current == nil || !r.Current.Available("temperature") {
+			continue
+		}
+		t := r.Current.Temperature
+		if s.Reporting == 0 || t < s.MinTemp {
+			s.MinTemp, s.Coldest = t, r.Current.Location
+		}
+		if s.Reporting == 0 || t > s.MaxTemp {
+			s.MaxTemp, s.Warmest = t, r.Current.Location
+		}
+		sum += t
+		s.Reporting++
+	}
+	if s.Reporting == 0 {
+		return s, false
+	}
+	s.AvgTemp = sum / float64(s.Reporting)
+	return s, true
+}
@@ -0,0 +1,154 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidLocation is returned for a location that is empty or only
+// whitespace, before any request is made.
+var ErrInvalidLocation = errors.New("invalid location: a zip code, city,state or lat,long is required")
+
+// ErrLocationNotFound is matched, with errors.Is, by the error providers
+// return when geocoding finds no place for a location.
+var ErrLocationNotFound = errors.New("location not found")
+
+// LocationNotFoundError reports a location geocoding couldn't find, with
+// places of similar names the user may have meant, if there are any.
+type LocationNotFoundError struct {
+	Location    string
+	Suggestions []string
+}
+
+func (e *LocationNotFoundError) Error() string {
+	msg := fmt.Sprintf("location not found: %s", e.Location)
+	if len(e.Suggestions) > 0 {
+		msg += "; did you mean: " + strings.Join(e.Suggestions, "? ") + "?"
+	}
+	return msg
+}
+
+func (e *LocationNotFoundError) Is(target error) bool {
+	return target == ErrLocationNotFound
+}
+
+// CheckLocation returns ErrInvalidLocation if location is blank.
+func CheckLocation(location string) error {
+	if strings.TrimSpace(location) == "" {
+		return ErrInvalidLocation
+	}
+	return nil
+}
+
+var coordinatesRE = regexp.MustCompile(`^\s*(-?\d+(?:\.\d+)?)\s*,\s*(-?\d+(?:\.\d+)?)\s*$`)
+
+// ParseCoordinates parses a "lat,long" location such as "42.36,-71.06". ok is
+// false if location isn't in that form or is out of range.
+func ParseCoordinates(location string) (lat, lon float64, ok bool) {
+	m := coordinatesRE.FindStringSubmatch(location)
+	if m == nil {
+		return 0, 0, false
+	}
+
+	lat, err := strconv.ParseFloat(m[1], 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, false
+	}
+	lon, err = strconv.ParseFloat(m[2], 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+
+	return lat, lon, true
+}
+
+// LocationKind is what form a location was given in. See ParseLocation.
+type LocationKind int
+
+const (
+	// LocationName is a place name alone, such as "Boston".
+	LocationName LocationKind = iota
+	// LocationZip is a 5-digit US zip code.
+	LocationZip
+	// LocationCityState is a place in a US state, DC or a territory, such
+	// as "Portland, OR", "Portland, Oregon" or "Washington, DC".
+	LocationCityState
+	// LocationCityCountry is a place and anything else after a comma,
+	// taken to be the country, such as "Paris, France".
+	LocationCityCountry
+	// LocationCoordinates is a latitude and longitude.
+	LocationCoordinates
+)
+
+var locationKindNames = map[LocationKind]string{
+	LocationName:        "name",
+	LocationZip:         "zip",
+	LocationCityState:   "city, state",
+	LocationCityCountry: "city, country",
+	LocationCoordinates: "coordinates",
+}
+
+// String returns the kind as Resolution.Form reports it, such as
+// "city, state".
+func (k LocationKind) String() string {
+	return locationKindNames[k]
+}
+
+// LocationQuery is a location broken into its parts by ParseLocation.
+type LocationQuery struct {
+	Kind LocationKind
+	// Input is the location as given, without surrounding space.
+	Input string
+	// Zip is set for LocationZip.
+	Zip string
+	// City is the place name of any kind but LocationZip and
+	// LocationCoordinates.
+	City string
+	// State is the postal abbreviation, such as "OR", "DC" or "PR", for
+	// LocationCityState, whether or not the state was given abbreviated.
+	State string
+	// Country is as given, such as "France" or "FR", for
+	// LocationCityCountry.
+	Country string
+	// Lat and Lng are set for LocationCoordinates.
+	Lat, Lng float64
+}
+
+var zipRE = regexp.MustCompile(`^[0-9]{5}$`)
+
+// ParseLocation classifies location as coordinates, a zip, a city and state,
+// a city and country or a name, which providers then look up as they can. It
+// returns ErrInvalidLocation if location is blank.
+func ParseLocation(location string) (LocationQuery, error) {
+	if err := CheckLocation(location); err != nil {
+		return LocationQuery{}, err
+	}
+	q := LocationQuery{Kind: LocationName, Input: strings.TrimSpace(location)}
+	if lat, lng, ok := ParseCoordinates(q.Input); ok {
+		q.Kind, q.Lat, q.Lng = LocationCoordinates, lat, lng
+		return q, nil
+	}
+	if zipRE.MatchString(q.Input) {
+		q.Kind, q.Zip = LocationZip, q.Input
+		return q, nil
+	}
+
+	q.City = q.Input
+	city, rest, ok := strings.Cut(q.Input, ",")
+	city, rest = strings.TrimSpace(city), strings.TrimSpace(rest)
+	if !ok || city == "" || rest == "" {
+		return q, nil
+	}
+	q.City = city
+	if abbr, ok := StateAbbreviation(rest); ok {
+		q.Kind, q.State = LocationCityState, abbr
+	} else if StateName(rest) != rest {
+		q.Kind, q.State = LocationCityState, strings.ToUpper(rest)
+	} else {
+		q.Kind, q.Country = LocationCityCountry, rest
+	}
+	return q, nil
+}
@@ -0,0 +1,67 @@
+package weather
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		location string
+		want     LocationQuery
+	}{
+		{"02134", LocationQuery{Kind: LocationZip, Input: "02134", Zip: "02134"}},
+		{" 42.36, -71.06 ", LocationQuery{Kind: LocationCoordinates, Input: "42.36, -71.06", Lat: 42.36, Lng: -71.06}},
+		{"Portland, OR", LocationQuery{Kind: LocationCityState, Input: "Portland, OR", City: "Portland", State: "OR"}},
+		{"Portland,or", LocationQuery{Kind: LocationCityState, Input: "Portland,or", City: "Portland", State: "OR"}},
+		{"Portland, Oregon", LocationQuery{Kind: LocationCityState, Input: "Portland, Oregon", City: "Portland", State: "OR"}},
+		{"Washington, DC", LocationQuery{Kind: LocationCityState, Input: "Washington, DC", City: "Washington", State: "DC"}},
+		{"Washington, District of Columbia", LocationQuery{Kind: LocationCityState, Input: "Washington, District of Columbia", City: "Washington", State: "DC"}},
+		{"San Juan, PR", LocationQuery{Kind: LocationCityState, Input: "San Juan, PR", City: "San Juan", State: "PR"}},
+		{"Hagåtña, Guam", LocationQuery{Kind: LocationCityState, Input: "Hagåtña, Guam", City: "Hagåtña", State: "GU"}},
+		{"Paris, France", LocationQuery{Kind: LocationCityCountry, Input: "Paris, France", City: "Paris", Country: "France"}},
+		{"Paris, FR", LocationQuery{Kind: LocationCityCountry, Input: "Paris, FR", City: "Paris", Country: "FR"}},
+		{"Boston", LocationQuery{Kind: LocationName, Input: "Boston", City: "Boston"}},
+		// Without both a city and what follows the comma, it's a name.
+		{"Boston,", LocationQuery{Kind: LocationName, Input: "Boston,", City: "Boston,"}},
+		// Out of range, so not coordinates.
+		{"91,0", LocationQuery{Kind: LocationCityCountry, Input: "91,0", City: "91", Country: "0"}},
+		// Four digits isn't a zip.
+		{"0213", LocationQuery{Kind: LocationName, Input: "0213", City: "0213"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseLocation(tt.location)
+		if err != nil {
+			t.Errorf("ParseLocation(%q): %v", tt.location, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLocation(%q) =\n%+v\nwant\n%+v", tt.location, got, tt.want)
+		}
+	}
+}
+
+func TestParseLocationBlank(t *testing.T) {
+	for _, location := range []string{"", "  \t"} {
+		if _, err := ParseLocation(location); !errors.Is(err, ErrInvalidLocation) {
+			t.Errorf("ParseLocation(%q) = %v, want ErrInvalidLocation", location, err)
+		}
+	}
+}
+
+func TestStates(t *testing.T) {
+	if abbr, ok := StateAbbreviation(" district of columbia "); !ok || abbr != "DC" {
+		t.Errorf("StateAbbreviation(district of columbia) = %q, %v, want DC", abbr, ok)
+	}
+	if got := StateName("vi"); got != "U.S. Virgin Islands" {
+		t.Errorf("StateName(vi) = %q, want U.S. Virgin Islands", got)
+	}
+	if got := StateName("XX"); got != "XX" {
+		t.Errorf("StateName(XX) = %q, want it unchanged", got)
+	}
+	for abbr, want := range map[string]bool{"PR": true, "gu": true, "DC": false, "OR": false} {
+		if got := Territory(abbr); got != want {
+			t.Errorf("Territory(%s) = %v, want %v", abbr, got, want)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MergeProvider gets the weather from several providers at once and blends
+// what they return into a consensus:
+//
+//   - Temperatures, wind speed, humidity, pressure, snowfall, and chance and
+//     amount of precipitation are averaged over the providers that have
+//     them, and wind direction is averaged as a compass bearing. All are in
+//     the same units whichever provider they're from, so they can be.
+//   - The condition is the one most providers report. A tie goes to the more
+//     severe by DefaultConditionSeverities, then to the earlier provider.
+//     The description and weather code are that provider's.
+//   - Everything else, such as the sun times and the coordinates, is the
+//     first provider's to succeed, in the order given.
+//
+// Forecast days are matched by date. Providers forecast different numbers of
+// days, so each day is blended from those that have it, and the forecast
+// runs as far as the longest. Providers that fail are left out; only if all
+// of them fail is there an error.
+type MergeProvider struct {
+	providers []Provider
+	timeout   time.Duration
+}
+
+type MergeOption func(*MergeProvider)
+
+// WithMergeTimeout leaves out a provider that takes longer than d, as
+// WithProviderTimeout does for a MultiProvider.
+func WithMergeTimeout(d time.Duration) MergeOption {
+	return func(m *MergeProvider) {
+		m.timeout = d
+	}
+}
+
+// NewMergeProvider returns a provider that blends the weather from providers.
+func NewMergeProvider(providers []Provider, opts ...MergeOption) *MergeProvider {
+	m := &MergeProvider{providers: providers}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *MergeProvider) GetCurrentWeather(location string) (*CurrentWeather, error) {
+	return m.GetCurrentWeatherContext(context.Background(), location)
+}
+
+func (m *MergeProvider) GetForecast(location string) (*Forecast, error) {
+	return m.GetForecastContext(context.Background(), location)
+}
+
+// GetCurrentWeatherContext is GetCurrentWeather with each provider's request
+// given a context derived from ctx.
+func (m *MergeProvider) GetCurrentWeatherContext(ctx context.Context, location string) (*CurrentWeather, error) {
+	ws, err := allResults(ctx, m, func(ctx context.Context, p Provider) (*CurrentWeather, error) {
+		return CurrentWeatherContext(ctx, p, location)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return MergeCurrent(ws), nil
+}
+
+// GetForecastContext is GetForecast with each provider's request given a
+// context derived from ctx.
+func (m *MergeProvider) GetForecastContext(ctx context.Context, location string) (*Forecast, error) {
+	fs, err := allResults(ctx, m, func(ctx context.Context, p Provider) (*Forecast, error) {
+		return ForecastContext(ctx, p, location)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return MergeForecasts(fs), nil
+}
+
+// Attribution credits every provider, since all of them contribute.
+func (m *MergeProvider) Attribution() string {
+	var credits []string
+	for _, p := range m.providers {
+		if a := p.Attribution(); a != "" && !slices.Contains(credits, a) {
+			credits = append(credits, a)
+		}
+	}
+	return strings.Join(credits, "; ")
+}
+
+// Capabilities are only those every provider has.
+func (m *MergeProvider) Capabilities() Capability {
+	if len(m.providers) == 0 {
+		return 0
+	}
+	caps := CapabilitiesOf(m.providers[0])
+	for _, p := range m.providers[1:] {
+		caps &= CapabilitiesOf(p)
+	}
+	return caps
+}
+
+// allResults calls fetch with all of m's providers at once, returning the
+// results of those that succeed in provider order, or every provider's error
+// if none does. An invalid location is invalid for all of them, so that
+// error is returned as is.
+func allResults[T any](ctx context.Context, m *MergeProvider, fetch func(context.Context, Provider) (*T, error)) ([]*T, error) {
+	results := make([]*T, len(m.providers))
+	errs := make([]error, len(m.providers))
+	var wg sync.WaitGroup
+	for i, p := range m.providers {
+		wg.Add(1)
+		go func(i int, p Provider) {
+			defer wg.Done()
+			results[i], errs[i] = attempt(ctx, p, m.timeout, fetch)
+		}(i, p)
+	}
+	wg.Wait()
+
+	var ok []*T
+	var failed []error
+	for i, err := range errs {
+		if errors.Is(err, ErrInvalidLocation) {
+			return nil, err
+		}
+		if err != nil {
+			failed = append(failed, fmt.Errorf("provider %d: %w", i+1, err))
+			continue
+		}
+		ok = append(ok, results[i])
+	}
+	if len(ok) == 0 {
+		return nil, errors.Join(failed...)
+	}
+	return ok, nil
+}
+
+// mean averages values, skipping those that aren't available.
+type mean struct {
+	sum float64
+	n   int
+}
+
+func (a *mean) add(v float64, available bool) {
+	if available {
+		a.sum += v
+		a.n++
+	}
+}
+
+// set stores the average in *v, or lists name in unavailable if there was
+// nothing to average.
+func (a *mean) set(v *float64, name string, unavailable *[]string) {
+	if a.n == 0 {
+		*v = 0
+		*unavailable = append(*unavailable, name)
+		return
+	}
+	*v = a.sum / float64(a.n)
+}
+
+func (a *mean) int() int {
+	if a.n == 0 {
+		return 0
+	}
+	return int(math.Round(a.sum / float64(a.n)))
+}
+
+// meanDirection averages compass bearings by adding them as unit vectors, so
+// that 350° and 10° average to 0° rather than 180°.
+type meanDirection struct {
+	x, y float64
+}
+
+func (a *meanDirection) add(degrees int, available bool) {
+	if available {
+		rad := float64(degrees) * math.Pi / 180
+		a.x += math.Sin(rad)
+		a.y += math.Cos(rad)
+	}
+}
+
+func (a *meanDirection) degrees() int {
+	if a.x == 0 && a.y == 0 {
+		return 0
+	}
+	d := int(math.Round(math.Atan2(a.x, a.y) * 180 / math.Pi))
+	return (d + 360) % 360
+}
+
+// modalCondition returns the index of the first of conditions with the most
+// common condition, the more severe winning a tie.
+func modalCondition(conditions []Condition) int {
+	counts := make(map[Condition]int)
+	for _, c := range conditions {
+		counts[c]++
+	}
+	best := 0
+	for i, c := range conditions {
+		b := conditions[best]
+		if counts[c] > counts[b] ||
+			counts[c] == counts[b] && DefaultConditionSeverities.Severity(c) > DefaultConditionSeverities.Severity(b) {
+			best = i
+		}
+	}
+	return best
+}
+
+// MergeCurrent blends the current weather from several providers, as
+// MergeProvider describes, taking all but the blended measurements from the
+// first. ws must not be empty. It's only cached if all of ws were, as of the
+// oldest.
+func MergeCurrent(ws []*CurrentWeather) *CurrentWeather {
+	merged := *ws[0]
+	merged.Unavailable, merged.Advisories = nil, nil
+
+	var temp, feels, high, low, wind, humidity, chance, precip, pressure mean
+	var direction meanDirection
+	conditions := make([]Condition, len(ws))
+	for i, w := range ws {
+		temp.add(w.Temperature, w.Available("temperature"))
+		feels.add(w.FeelsLike, w.Available("feels_like"))
+		high.add(w.TempMax, w.Available("temp_max"))
+		low.add(w.TempMin, w.Available("temp_min"))
+		wind.add(w.WindSpeed, w.Available("wind_speed"))
+		direction.add(w.WindDirection, w.Available("wind_speed") && w.WindSpeed != 0)
+		humidity.add(float64(w.Humidity), true)
+		chance.add(float64(w.PrecipProbability), true)
+		precip.add(w.Precipitation, w.Available("precipitation"))
+		// Zero is a provider not reporting it, not a vacuum.
+		pressure.add(w.Pressure, w.Available("pressure") && w.Pressure != 0)
+		conditions[i] = w.Condition
+
+		if w.CachedAt.IsZero() {
+			merged.CachedAt = time.Time{}
+		} else if !merged.CachedAt.IsZero() && w.CachedAt.Before(merged.CachedAt) {
+			merged.CachedAt = w.CachedAt
+		}
+	}
+
+	temp.set(&merged.Temperature, "temperature", &merged.Unavailable)
+	feels.set(&merged.FeelsLike, "feels_like", &merged.Unavailable)
+	high.set(&merged.TempMax, "temp_max", &merged.Unavailable)
+	low.set(&merged.TempMin, "temp_min", &merged.Unavailable)
+	wind.set(&merged.WindSpeed, "wind_speed", &merged.Unavailable)
+	precip.set(&merged.Precipitation, "precipitation", &merged.Unavailable)
+	merged.WindDirection = direction.degrees()
+	merged.Humidity = humidity.int()
+	merged.PrecipProbability = chance.int()
+	if pressure.n > 0 {
+		merged.Pressure = pressure.sum / float64(pressure.n)
+	}
+	if !ws[0].Available("elevation") {
+		merged.Unavailable = append(merged.Unavailable, "elevation")
+	}
+
+	modal := ws[modalCondition(conditions)]
+	merged.Condition = modal.Condition
+	merged.Conditions = modal.Conditions
+	merged.WeatherCode = modal.WeatherCode
+	merged.PrecipType = DetectPrecipType(merged.Condition, merged.Temperature)
+	return &merged
+}
+
+// mergeDays blends the forecasts for one day, as MergeCurrent does.
+func mergeDays(days []DailyForecast) DailyForecast {
+	merged := days[0]
+	merged.Unavailable = nil
+
+	var high, low, wind, humidity, chance, precip, snow mean
+	var direction meanDirection
+	conditions := make([]Condition, len(days))
+	for i, d := range days {
+		high.add(d.High, d.Available("high"))
+		low.add(d.Low, d.Available("low"))
+		wind.add(d.WindSpeed, d.Available("wind_speed"))
+		direction.add(d.WindDirection, d.Available("wind_speed") && d.WindSpeed != 0)
+		humidity.add(float64(d.Humidity), true)
+		chance.add(float64(d.PrecipProbability), true)
+		precip.add(d.Precipitation, d.Available("precipitation"))
+		// A provider that doesn't report snowfall leaves it zero, which
+		// isn't a forecast of none.
+		snow.add(d.Snowfall, d.Available("snowfall") && d.Snowfall != 0)
+		conditions[i] = d.Condition
+	}
+
+	high.set(&merged.High, "high", &merged.Unavailable)
+	low.set(&merged.Low, "low", &merged.Unavailable)
+	wind.set(&merged.WindSpeed, "wind_speed", &merged.Unavailable)
+	precip.set(&merged.Precipitation, "precipitation", &merged.Unavailable)
+	merged.Snowfall = 0
+	if snow.n > 0 {
+		merged.Snowfall = snow.sum / float64(snow.n)
+	}
+	merged.WindDirection = direction.degrees()
+	merged.Humidity = humidity.int()
+	merged.PrecipProbability = chance.int()
+
+	modal := days[modalCondition(conditions)]
+	merged.Condition = modal.Condition
+	merged.Conditions = modal.Conditions
+	merged.WeatherCode = modal.WeatherCode
+	merged.PrecipType = DetectPrecipType(merged.Condition, merged.High)
+	return merged
+}
+
+// MergeForecasts blends forecasts from several providers, as MergeProvider
+// describes: each date any of them has is blended from those that have it.
+// fs must not be empty.
+func MergeForecasts(fs []*Forecast) *Forecast {
+	merged := &Forecast{Location: fs[0].Location, CachedAt: fs[0].CachedAt}
+
+	var currents []*CurrentWeather
+	byDate := make(map[time.Time][]DailyForecast)
+	var dates []time.Time
+	for _, f := range fs {
+		if f.Current != nil {
+			currents = append(currents, f.Current)
+		}
+		if f.CachedAt.IsZero() {
+			merged.CachedAt = time.Time{}
+		} else if !merged.CachedAt.IsZero() && f.CachedAt.Before(merged.CachedAt) {
+			merged.CachedAt = f.CachedAt
+		}
+		for _, day := range f.DailyItems {
+			// Dates are midnight UTC of the location's day, but compare
+			// them as such whatever zone a provider left them in.
+			date := day.Date.UTC()
+			if _, ok := byDate[date]; !ok {
+				dates = append(dates, date)
+			}
+			byDate[date] = append(byDate[date], day)
+		}
+	}
+
+	if len(currents) > 0 {
+		merged.Current = MergeCurrent(currents)
+	}
+	slices.SortFunc(dates, func(a, b time.Time) int { return a.Compare(b) })
+	for _, date := range dates {
+		merged.DailyItems = append(merged.DailyItems, mergeDays(byDate[date]))
+	}
+	return merged
+}
@@ -0,0 +1,90 @@
+package weather
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// fixtureProvider returns the same weather for any location.
+type fixtureProvider struct {
+	current  *CurrentWeather
+	forecast *Forecast
+}
+
+func (p *fixtureProvider) GetCurrentWeather(string) (*CurrentWeather, error) {
+	w := *p.current
+	return &w, nil
+}
+
+func (p *fixtureProvider) GetForecast(string) (*Forecast, error) {
+	f := *p.forecast
+	return &f, nil
+}
+
+func (p *fixtureProvider) Attribution() string { return "" }
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestMergeProviderWindSpeed(t *testing.T) {
+	day1 := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	a := &fixtureProvider{
+		current: &CurrentWeather{Temperature: 50, WindSpeed: 10, WindDirection: 350, Condition: ConditionClear},
+		forecast: &Forecast{DailyItems: []DailyForecast{
+			{Date: day1, High: 60, WindSpeed: 8, Condition: ConditionClear},
+		}},
+	}
+	b := &fixtureProvider{
+		current: &CurrentWeather{Temperature: 54, WindSpeed: 20, WindDirection: 10, Condition: ConditionClear},
+		forecast: &Forecast{DailyItems: []DailyForecast{
+			{Date: day1, High: 62, WindSpeed: 12, Condition: ConditionClear},
+			{Date: day2, High: 58, WindSpeed: 30, Condition: ConditionRain},
+		}},
+	}
+	m := NewMergeProvider([]Provider{a, b})
+
+	w, err := m.GetCurrentWeather("Boston, MA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(w.WindSpeed, 15) {
+		t.Errorf("merged wind speed = %v mph, want 15", w.WindSpeed)
+	}
+	if w.WindDirection != 0 {
+		t.Errorf("merged wind direction = %v, want 0", w.WindDirection)
+	}
+	if !near(w.Temperature, 52) {
+		t.Errorf("merged temperature = %v, want 52", w.Temperature)
+	}
+
+	f, err := m.GetForecast("Boston, MA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.DailyItems) != 2 {
+		t.Fatalf("merged forecast has %d days, want 2", len(f.DailyItems))
+	}
+	// The first day is blended from both, the second is b's alone.
+	for i, want := range []float64{10, 30} {
+		if got := f.DailyItems[i].WindSpeed; !near(got, want) {
+			t.Errorf("day %d wind speed = %v mph, want %v", i+1, got, want)
+		}
+	}
+}
+
+func TestMergeCurrentSkipsUnreported(t *testing.T) {
+	ws := []*CurrentWeather{
+		{WindSpeed: 10, Pressure: 30.1},
+		{WindSpeed: 0, Unavailable: []string{"wind_speed"}},
+	}
+	w := MergeCurrent(ws)
+	if !near(w.WindSpeed, 10) {
+		t.Errorf("wind speed = %v, want 10 from the one provider with it", w.WindSpeed)
+	}
+	if !near(w.Pressure, 30.1) {
+		t.Errorf("pressure = %v, want 30.1 from the one provider with it", w.Pressure)
+	}
+}
@@ -0,0 +1,132 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrProviderTimeout is matched, with errors.Is, by the error for a provider
+// in a MultiProvider that took longer than its timeout.
+var ErrProviderTimeout = errors.New("provider timed out")
+
+// MultiProvider gets the weather from the first of several providers that
+// succeeds, trying them in order, so one being down or slow doesn't stop the
+// weather being shown.
+type MultiProvider struct {
+	providers []Provider
+	timeout   time.Duration
+}
+
+type MultiOption func(*MultiProvider)
+
+// WithProviderTimeout gives each provider at most d before moving on to the
+// next, so a slow provider doesn't use up the time the others could have.
+// Each attempt's context has the deadline, so a ContextProvider's request is
+// stopped; any other provider's is abandoned.
+func WithProviderTimeout(d time.Duration) MultiOption {
+	return func(m *MultiProvider) {
+		m.timeout = d
+	}
+}
+
+// NewMultiProvider returns a provider that tries providers in order.
+func NewMultiProvider(providers []Provider, opts ...MultiOption) *MultiProvider {
+	m := &MultiProvider{providers: providers}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *MultiProvider) GetCurrentWeather(location string) (*CurrentWeather, error) {
+	return m.GetCurrentWeatherContext(context.Background(), location)
+}
+
+func (m *MultiProvider) GetForecast(location string) (*Forecast, error) {
+	return m.GetForecastContext(context.Background(), location)
+}
+
+// GetCurrentWeatherContext is GetCurrentWeather with each provider's attempt
+// given a context derived from ctx.
+func (m *MultiProvider) GetCurrentWeatherContext(ctx context.Context, location string) (*CurrentWeather, error) {
+	return firstResult(ctx, m, func(ctx context.Context, p Provider) (*CurrentWeather, error) {
+		return CurrentWeatherContext(ctx, p, location)
+	})
+}
+
+// GetForecastContext is GetForecast with each provider's attempt given a
+// context derived from ctx.
+func (m *MultiProvider) GetForecastContext(ctx context.Context, location string) (*Forecast, error) {
+	return firstResult(ctx, m, func(ctx context.Context, p Provider) (*Forecast, error) {
+		return ForecastContext(ctx, p, location)
+	})
+}
+
+// Attribution credits every provider, since any of them may have served the
+// data.
+func (m *MultiProvider) Attribution() string {
+	var credits []string
+	for _, p := range m.providers {
+		if a := p.Attribution(); a != "" && !slices.Contains(credits, a) {
+			credits = append(credits, a)
+		}
+	}
+	return strings.Join(credits, "; ")
+}
+
+// Capabilities are only those every provider has, as it isn't known which
+// will serve a request.
+func (m *MultiProvider) Capabilities() Capability {
+	if len(m.providers) == 0 {
+		return 0
+	}
+	caps := CapabilitiesOf(m.providers[0])
+	for _, p := range m.providers[1:] {
+		caps &= CapabilitiesOf(p)
+	}
+	return caps
+}
+
+// firstResult calls fetch with each of m's providers until one succeeds,
+// returning every provider's error if none does. An invalid location is
+// invalid for all of them, so it's returned straight away, as is ctx's error
+// once it's done.
+func firstResult[T any](ctx context.Context, m *MultiProvider, fetch func(context.Context, Provider) (T, error)) (T, error) {
+	var errs []error
+	for i, p := range m.providers {
+		result, err := attempt(ctx, p, m.timeout, fetch)
+		if err == nil {
+			return result, nil
+		}
+		if errors.Is(err, ErrInvalidLocation) {
+			return result, err
+		}
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		errs = append(errs, fmt.Errorf("provider %d: %w", i+1, err))
+	}
+	var zero T
+	return zero, errors.Join(errs...)
+}
+
+// attempt calls fetch with p and a context derived from ctx that times out
+// after timeout, unless it's zero.
+func attempt[T any](ctx context.Context, p Provider, timeout time.Duration, fetch func(context.Context, Provider) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return fetch(ctx, p)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, err := fetch(attemptCtx, p)
+	if err != nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		var zero T
+		return zero, fmt.Errorf("%w after %s", ErrProviderTimeout, timeout)
+	}
+	return result, err
+}
@@ -0,0 +1,86 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// hangingProvider never answers, but stops when its context is done, noting
+// that it was.
+type hangingProvider struct {
+	cancelled chan error
+}
+
+func (p *hangingProvider) GetCurrentWeather(location string) (*CurrentWeather, error) {
+	return p.GetCurrentWeatherContext(context.Background(), location)
+}
+
+func (p *hangingProvider) GetForecast(location string) (*Forecast, error) {
+	return p.GetForecastContext(context.Background(), location)
+}
+
+func (p *hangingProvider) GetCurrentWeatherContext(ctx context.Context, _ string) (*CurrentWeather, error) {
+	<-ctx.Done()
+	p.cancelled <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func (p *hangingProvider) GetForecastContext(ctx context.Context, _ string) (*Forecast, error) {
+	<-ctx.Done()
+	p.cancelled <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func (p *hangingProvider) Attribution() string { return "" }
+
+// A provider that takes too long has its request stopped, by its context's
+// deadline, and the next provider is used.
+func TestMultiProviderTimeoutCancelsAttempt(t *testing.T) {
+	slow := &hangingProvider{cancelled: make(chan error, 1)}
+	fast := &fixtureProvider{current: &CurrentWeather{Temperature: 50}}
+	m := NewMultiProvider([]Provider{slow, fast}, WithProviderTimeout(10*time.Millisecond))
+
+	w, err := m.GetCurrentWeather("Boston, MA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Temperature != 50 {
+		t.Errorf("temperature = %v, want the second provider's 50", w.Temperature)
+	}
+	select {
+	case err := <-slow.cancelled:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("slow provider's context ended with %v, want the deadline", err)
+		}
+	default:
+		t.Error("slow provider's context wasn't done when the next was tried")
+	}
+}
+
+// When every provider times out, each error says so.
+func TestMultiProviderAllTimeOut(t *testing.T) {
+	slow := &hangingProvider{cancelled: make(chan error, 2)}
+	m := NewMultiProvider([]Provider{slow, slow}, WithProviderTimeout(10*time.Millisecond))
+
+	_, err := m.GetForecast("Boston, MA")
+	if !errors.Is(err, ErrProviderTimeout) {
+		t.Errorf("err = %v, want ErrProviderTimeout", err)
+	}
+}
+
+// Cancelling the caller's context stops the failover: the rest of the
+// providers aren't tried, and its error is returned.
+func TestMultiProviderCallerCancelled(t *testing.T) {
+	slow := &hangingProvider{cancelled: make(chan error, 1)}
+	fast := &fixtureProvider{current: &CurrentWeather{Temperature: 50}}
+	m := NewMultiProvider([]Provider{slow, fast}, WithProviderTimeout(time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := m.GetCurrentWeatherContext(ctx, "Boston, MA")
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrProviderTimeout) {
+		t.Errorf("err = %v, want the caller's deadline", err)
+	}
+}
@@ -0,0 +1,120 @@
+package openmeteo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// Climate normals are averaged over the standard WMO 30 year period.
+const (
+	normalsFirstYear = 1991
+	normalsLastYear  = 2020
+)
+
+// normalsWindow is how many days either side of the date are averaged too,
+// to smooth out the noise of single days.
+const normalsWindow = 3
+
+// normalsTTL is how long archive responses are cached. The period is in the
+// past, so they only change when the archive is reprocessed.
+const normalsTTL = 30 * 24 * time.Hour
+
+// ArchiveResponse is the part of a historical weather API response used for
+// climate normals. Days without data are null.
+type ArchiveResponse struct {
+	Daily struct {
+		Time    []string   `json:"time"`
+		TempMax []*float64 `json:"temperature_2m_max"`
+		TempMin []*float64 `json:"temperature_2m_min"`
+	} `json:"daily"`
+}
+
+func (p *Provider) archiveURL(lat, lon float64) string {
+	return fmt.Sprintf("%s/v1/archive?latitude=%f&longitude=%f&start_date=%d-01-01&end_date=%d-12-31&daily=temperature_2m_max,temperature_2m_min&temperature_unit=fahrenheit&timezone=auto",
+		p.archiveBase, lat, lon, normalsFirstYear, normalsLastYear)
+}
+
+// Capabilities reports the optional features Open-Meteo supports. A
+// seven-day forecast needs WithForecastDays.
+func (p *Provider) Capabilities() weather.Capability {
+	caps := weather.CapClimateNormals
+	if p.forecastDays >= 7 {
+		caps |= weather.CapWeekForecast
+	}
+	return caps
+}
+
+// ClimateNormal returns the average high and low for location on date's day
+// of the year, and each year's on that date, from the historical weather
+// archive. The whole period is one
+// request, which is cached for every date if a cache is set.
+func (p *Provider) ClimateNormal(location string, date time.Time) (*weather.ClimateNormal, error) {
+	if err := weather.CheckLocation(location); err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	coords, err := p.getCoordinates(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	var data ArchiveResponse
+	if _, err := p.fetchCached(ctx, "archive", p.archiveURL(coords.Latitude, coords.Longitude), &data, p.normalsCache, normalsTTL); err != nil {
+		return nil, err
+	}
+
+	var highSum, lowSum float64
+	var n int
+	var history []weather.PastDay
+	days := min(len(data.Daily.Time), len(data.Daily.TempMax), len(data.Daily.TempMin))
+	for i := 0; i < days; i++ {
+		if data.Daily.TempMax[i] == nil || data.Daily.TempMin[i] == nil {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", data.Daily.Time[i])
+		if err != nil || !nearDayOfYear(day, date, normalsWindow) {
+			continue
+		}
+		highSum += *data.Daily.TempMax[i]
+		lowSum += *data.Daily.TempMin[i]
+		n++
+		if day.Month() == date.Month() && day.Day() == date.Day() {
+			history = append(history, weather.PastDay{
+				Year: day.Year(),
+				High: *data.Daily.TempMax[i],
+				Low:  *data.Daily.TempMin[i],
+			})
+		}
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("no climate data available for %s", coords.Name)
+	}
+
+	return &weather.ClimateNormal{
+		Date:      date,
+		High:      highSum / float64(n),
+		Low:       lowSum / float64(n),
+		FirstYear: normalsFirstYear,
+		LastYear:  normalsLastYear,
+		History:   history,
+	}, nil
+}
+
+// nearDayOfYear reports whether day is within window days of date's month
+// and day in any year, including across the new year.
+func nearDayOfYear(day, date time.Time, window int) bool {
+	for year := day.Year() - 1; year <= day.Year()+1; year++ {
+		same := time.Date(year, date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		diff := day.Sub(same)
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff <= time.Duration(window)*24*time.Hour {
+			return true
+		}
+	}
+	return false
+}
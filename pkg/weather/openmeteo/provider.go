@@ -1,16 +1,21 @@
 package openmeteo
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
-	"regexp"
+	"path"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/duluk/weather/pkg/weather"
+	"golang.org/x/sync/singleflight"
 )
 
 /* --> Response to GetCurrentWeather:
@@ -42,24 +47,186 @@ import (
 */
 
 type WeatherResponse struct {
-	CurrentWeather struct {
+	// Latitude and Longitude are the grid point the data is for, which may
+	// differ from the coordinates requested.
+	Latitude             float64 `json:"latitude"`
+	Longitude            float64 `json:"longitude"`
+	UTCOffsetSeconds     int     `json:"utc_offset_seconds"`
+	Timezone             string  `json:"timezone"`
+	TimezoneAbbreviation string  `json:"timezone_abbreviation"`
+	Elevation            float64 `json:"elevation"`
+	CurrentWeather       struct {
+		// Time is local to the location, e.g. "2025-02-15T03:30".
+		Time             string  `json:"time"`
 		Temperature      float64 `json:"temperature_2m"`
 		WindSpeed        float64 `json:"windspeed_10m"`
 		WeatherCode      int     `json:"weathercode"`
 		RelativeHumidity int     `json:"relativehumidity_2m"`
+		WindDirection    int     `json:"winddirection_10m"`
+		// CloudCover is a percentage, or nil if the response has none.
+		CloudCover *float64 `json:"cloud_cover"`
+		// Pressure is at sea level, in hPa whatever the other units.
+		Pressure float64 `json:"pressure_msl"`
 	} `json:"current"`
 	Daily struct {
-		Time             []string  `json:"time"`
-		TempMax          []float64 `json:"temperature_2m_max"`
-		TempMin          []float64 `json:"temperature_2m_min"`
-		WindSpeed        []float64 `json:"windspeed_10m_max"`
-		WeatherCode      []int     `json:"weathercode"`
-		RelativeHumidity []int     `json:"relative_humidity_2m_max"`
+		Time              []string   `json:"time"`
+		TempMax           []float64  `json:"temperature_2m_max"`
+		TempMin           []float64  `json:"temperature_2m_min"`
+		WindSpeed         []float64  `json:"windspeed_10m_max"`
+		WeatherCode       []int      `json:"weathercode"`
+		RelativeHumidity  []int      `json:"relative_humidity_2m_max"`
+		WindDirection     []int      `json:"winddirection_10m_dominant"`
+		PrecipProbability []int      `json:"precipitation_probability_max"`
+		Sunrise           []string   `json:"sunrise"`
+		Sunset            []string   `json:"sunset"`
+		CloudCover        []*float64 `json:"cloud_cover_mean"`
+		// Snowfall is in inches, as precipitation_unit=inch gives it.
+		Snowfall []float64 `json:"snowfall_sum"`
 	} `json:"daily"`
+	// Hourly starts at the current hour and covers rainHours hours.
+	Hourly struct {
+		Time              []string  `json:"time"`
+		Precipitation     []float64 `json:"precipitation"`
+		PrecipProbability []int     `json:"precipitation_probability"`
+	} `json:"hourly"`
+	// Minutely15 is the precipitation in 15-minute steps over the same
+	// hours, if WithQuarterHourly asked for it. The probability isn't
+	// available in 15-minute steps, so it comes from Hourly. Amounts are nil
+	// where the model has none.
+	Minutely15 struct {
+		Time          []string   `json:"time"`
+		Precipitation []*float64 `json:"precipitation"`
+	} `json:"minutely_15"`
 }
 
+/* --> Response to a request with invalid parameters (HTTP 400):
+{
+  "error": true,
+  "reason": "Cannot initialize WeatherVariable from invalid String value tempeature_2m for key hourly"
+}
+*/
+
+type ErrorResponse struct {
+	Error  bool   `json:"error"`
+	Reason string `json:"reason"`
+}
+
+const (
+	defaultGeocodingURL = "https://geocoding-api.open-meteo.com"
+	defaultForecastURL  = "https://api.open-meteo.com"
+	defaultArchiveURL   = "https://archive-api.open-meteo.com"
+)
+
 type Provider struct {
-	debugMode bool
+	debugMode     bool
+	cache         weather.Cache
+	cacheTTL      time.Duration
+	normalsCache  weather.Cache
+	timing        weather.TimingFunc
+	geocodingBase string
+	forecastBase  string
+	archiveBase   string
+	zipFallback   string
+	quarterHourly bool
+	landmarks     bool
+	forecastDays  int
+	fixtures      *weather.Fixtures
+	flight        singleflight.Group
+}
+
+type Option func(*Provider)
+
+// WithFixtures records each response in f, or replays them from it instead
+// of making requests, as f.Record says. The endpoints are geocode, suggest
+// (the search for suggestions when a location isn't found), current,
+// forecast and archive.
+func WithFixtures(f *weather.Fixtures) Option {
+	return func(p *Provider) {
+		p.fixtures = f
+	}
+}
+
+// WithBaseURL sends geocoding, forecast and archive requests to base, such
+// as a local test server or proxy, instead of the Open-Meteo hosts. The
+// /v1/search, /v1/forecast and /v1/archive paths are appended as usual.
+func WithBaseURL(base string) Option {
+	return func(p *Provider) {
+		base = strings.TrimSuffix(base, "/")
+		p.geocodingBase = base
+		p.forecastBase = base
+		p.archiveBase = base
+	}
+}
+
+// WithCache serves responses from c when present and stores new ones in it
+// for ttl. Climate normals are cached in it too, unless WithNormalsCache
+// gives them their own.
+func WithCache(c weather.Cache, ttl time.Duration) Option {
+	return func(p *Provider) {
+		p.cache = c
+		p.cacheTTL = ttl
+		if p.normalsCache == nil {
+			p.normalsCache = c
+		}
+	}
+}
+
+// WithTiming calls fn with how long each request took.
+func WithTiming(fn weather.TimingFunc) Option {
+	return func(p *Provider) {
+		p.timing = fn
+	}
+}
+
+// WithNormalsCache caches the climate normals ClimateNormal looks up in c,
+// for normalsTTL, even when other responses aren't cached.
+func WithNormalsCache(c weather.Cache) Option {
+	return func(p *Provider) {
+		p.normalsCache = c
+	}
+}
+
+// WithZipFallback looks up zip instead when a location isn't found, for names
+// such as "Springfield, IL" that may not geocode as meant.
+func WithZipFallback(zip string) Option {
+	return func(p *Provider) {
+		p.zipFallback = zip
+	}
+}
+
+// WithQuarterHourly asks for the precipitation forecast in
+// CurrentWeather.Hourly in 15-minute steps instead of hourly. Where the model
+// has no 15-minute data, the hourly forecast is used instead.
+func WithQuarterHourly() Option {
+	return func(p *Provider) {
+		p.quarterHourly = true
+	}
+}
+
+// DefaultForecastDays is how many days GetForecast returns, after today,
+// unless WithForecastDays says otherwise, and MaxForecastDays the most it
+// can, as the API forecasts 16 days including today.
+const (
+	DefaultForecastDays = 5
+	MaxForecastDays     = 15
+)
+
+// WithForecastDays sets how many days after today GetForecast returns, from
+// 1 to MaxForecastDays.
+func WithForecastDays(n int) Option {
+	return func(p *Provider) {
+		p.forecastDays = max(1, min(n, MaxForecastDays))
+	}
+}
+
+// WithLandmarks lets locations resolve to places other than cities, towns and
+// villages, such as parks, mountains and neighborhoods, which are otherwise
+// left out of geocoding results so that "Central Park" doesn't end up at an
+// odd feature.
+func WithLandmarks() Option {
+	return func(p *Provider) {
+		p.landmarks = true
+	}
 }
 
 /* Example Geocoding structure response:
@@ -91,193 +258,746 @@ type Provider struct {
 */
 
 type GeocodingResult struct {
-	Name      string  `json:"name"`
-	State     string  `json:"admin1"`
-	Country   string  `json:"country"`
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
+	Name        string  `json:"name"`
+	State       string  `json:"admin1"`
+	Country     string  `json:"country"`
+	CountryCode string  `json:"country_code"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Population  int     `json:"population"`
+	// FeatureCode is the GeoNames feature code, such as "PPLA2" for a
+	// county seat or "PRK" for a park.
+	FeatureCode string `json:"feature_code"`
+}
+
+// populated reports whether r is a populated place (a GeoNames PPL code),
+// such as a city or village, rather than a landmark or other feature. A
+// result without a code is given the benefit of the doubt.
+func (r GeocodingResult) populated() bool {
+	return r.FeatureCode == "" || strings.HasPrefix(r.FeatureCode, "PPL")
+}
+
+// populatedPlaces returns the populated places of results, or all of them
+// with WithLandmarks.
+func (p *Provider) populatedPlaces(results []GeocodingResult) []GeocodingResult {
+	if p.landmarks {
+		return results
+	}
+	var places []GeocodingResult
+	for _, r := range results {
+		if r.populated() {
+			places = append(places, r)
+		}
+	}
+	return places
 }
 
 type GeocodingResponse struct {
 	Results []GeocodingResult `json:"results"`
 }
 
-func (p *Provider) getCoordinates(location string) (*GeocodingResult, error) {
-	var count int
-	var state string
-	if regexp.MustCompile(`^[0-9]{5}$`).MatchString(location) {
-		location = fmt.Sprintf("%s", location)
-		count = 1
-	} else if regexp.MustCompile(`^[a-zA-Z ]+, ?[A-Z]{2}$`).MatchString(location) {
-		parts := strings.Split(location, ",")
-		if len(parts) == 2 {
-			city := strings.TrimSpace(parts[0])
-			state = strings.TrimSpace(parts[1])
-			location = fmt.Sprintf("%s", city)
+func (p *Provider) getCoordinates(ctx context.Context, location string) (*GeocodingResult, error) {
+	result, _, err := p.resolveLocation(ctx, location)
+	return result, err
+}
+
+// ExplainLocation returns how location is resolved, making the same requests
+// as fetching the weather for it would.
+func (p *Provider) ExplainLocation(location string) (*weather.Resolution, error) {
+	if err := weather.CheckLocation(location); err != nil {
+		return nil, err
+	}
+	_, res, err := p.resolveLocation(context.Background(), location)
+	return res, err
+}
+
+// resolveLocation geocodes location, falling back to the zip given with
+// WithZipFallback if it isn't found, and explains how it did.
+func (p *Provider) resolveLocation(ctx context.Context, location string) (*GeocodingResult, *weather.Resolution, error) {
+	result, res, err := p.lookupCoordinates(ctx, location)
+	var notFound *weather.LocationNotFoundError
+	if !errors.As(err, &notFound) || p.zipFallback == "" || location == p.zipFallback {
+		return result, res, err
+	}
+
+	if p.debugMode {
+		fmt.Printf("Debug getCoordinates: %s not found, trying zip %s\n", location, p.zipFallback)
+	}
+	if result, zipRes, zipErr := p.lookupCoordinates(ctx, p.zipFallback); zipErr == nil {
+		zipRes.Input = location
+		zipRes.Reason = fmt.Sprintf("%s wasn't found, so the fallback zip %s was used: %s", location, p.zipFallback, zipRes.Reason)
+		return result, zipRes, nil
+	}
+	// The suggestions for the name are more use than none for the zip.
+	return nil, res, err
+}
+
+// lookupCoordinates geocodes location, without falling back to the zip, and
+// explains how it did.
+func (p *Provider) lookupCoordinates(ctx context.Context, location string) (*GeocodingResult, *weather.Resolution, error) {
+	res := &weather.Resolution{Input: location, Name: location}
+	q, err := weather.ParseLocation(location)
+	if err != nil {
+		return nil, res, err
+	}
+	res.Form = q.Kind.String()
+
+	// Open-Meteo has no reverse geocoding, so coordinates are used as given
+	// and also serve as the location name.
+	if q.Kind == weather.LocationCoordinates {
+		result := &GeocodingResult{
+			Name:      fmt.Sprintf("%.4f,%.4f", q.Lat, q.Lng),
+			Latitude:  q.Lat,
+			Longitude: q.Lng,
 		}
-		count = 10
-	} else {
-		location = fmt.Sprintf("%s", location)
+		res.Reason = "coordinates are used as given"
+		return result, chosen(res, result), nil
 	}
 
-	url := fmt.Sprintf("https://geocoding-api.open-meteo.com/v1/search?name=%s&count=%d&language=en&format=json",
-		url.QueryEscape(location), count)
+	url := p.geocodingURL(q)
+	res.Query = url
+	if q.Kind == weather.LocationCityState || q.Kind == weather.LocationCityCountry {
+		res.Name = q.City
+	}
+	res.State = q.State
+	state := q.State
 
 	var data GeocodingResponse
-	if err := p.fetchData(url, &data); err != nil {
-		return nil, err
+	if _, err := p.fetchData(ctx, "geocode", url, &data); err != nil {
+		return nil, res, err
 	}
+	anyFeatures := len(data.Results) > 0
+	data.Results = p.populatedPlaces(data.Results)
+	res.Candidates = len(data.Results)
 
 	if len(data.Results) == 0 {
-		return nil, fmt.Errorf("location not found: %s", location)
+		err := error(&weather.LocationNotFoundError{
+			Location:    location,
+			Suggestions: p.suggestLocations(ctx, location),
+		})
+		if anyFeatures {
+			err = fmt.Errorf("%w (only landmarks or other places that aren't cities or towns match)", err)
+		}
+		return nil, res, err
 	}
 
 	// Open-Meteo API doesn't allow the state in the query but returns it in
 	// the response, so we have to match it ourselves. That is, it wil return
 	// all cities that match the name, so we have to filter by state.
+	//
+	// Several places of the same name can be in one state (there are a few
+	// Springfields in some), so prefer the most populous, which is usually
+	// the one meant.
+	//
+	// Some results have no state at all. Those in the US could be the place
+	// meant, so the most populous is used if none is in the state.
 	if state != "" {
-		for _, result := range data.Results {
-			if matchedState(result.State, state) {
-				return &result, nil
+		var best, stateless *GeocodingResult
+		matches := 0
+		for i, result := range data.Results {
+			if result.State == "" && result.CountryCode == "US" {
+				if stateless == nil || result.Population > stateless.Population {
+					stateless = &data.Results[i]
+				}
+				continue
+			}
+			if !matchedState(result, state) {
+				continue
+			}
+			matches++
+			if best == nil || result.Population > best.Population {
+				best = &data.Results[i]
+			}
+		}
+		if best == nil && stateless != nil {
+			res.Reason = fmt.Sprintf("none of the %d results is in %s, but this one has no state and may be", len(data.Results), state)
+			return stateless, chosen(res, stateless), nil
+		}
+		if best == nil {
+			// The place exists, just not in that state, so the other states'
+			// are the suggestions.
+			sort.SliceStable(data.Results, func(i, j int) bool {
+				return data.Results[i].Population > data.Results[j].Population
+			})
+			return nil, res, &weather.LocationNotFoundError{
+				Location:    location,
+				Suggestions: suggestionNames(data.Results),
+			}
+		}
+		res.Reason = fmt.Sprintf("the most populous of the %d results in %s", matches, state)
+		if matches == 1 {
+			res.Reason = fmt.Sprintf("the only one of the %d results in %s", len(data.Results), state)
+		}
+		return best, chosen(res, best), nil
+	}
+
+	// The geocoder doesn't take a country either, but its results are
+	// ranked, so the first in the country is its best match there. If
+	// none is, the "country" may not be one, and the best match overall
+	// is used as for a name.
+	if q.Kind == weather.LocationCityCountry {
+		for i, result := range data.Results {
+			if strings.EqualFold(result.Country, q.Country) || strings.EqualFold(result.CountryCode, q.Country) {
+				res.Reason = fmt.Sprintf("the geocoder's best match in %s", result.Country)
+				return &data.Results[i], chosen(res, &data.Results[i]), nil
+			}
+		}
+	}
+
+	res.Reason = "the geocoder's best match, listed first"
+	if len(data.Results) == 1 {
+		res.Reason = "the only result"
+	}
+	return &data.Results[0], chosen(res, &data.Results[0]), nil
+}
+
+// chosen records result as the place res resolved to.
+func chosen(res *weather.Resolution, result *GeocodingResult) *weather.Resolution {
+	res.Chosen = weather.Place{
+		Name:       result.Name,
+		State:      result.State,
+		Country:    result.Country,
+		Latitude:   result.Latitude,
+		Longitude:  result.Longitude,
+		Population: result.Population,
+	}
+	return res
+}
+
+// maxSuggestions is the most alternatives offered for a location that isn't
+// found.
+const maxSuggestions = 5
+
+// suggestLocations searches again for a location that wasn't found, with
+// only the start of its name, and returns the names of the results closest
+// in spelling, best first. The search is best effort: any failure just
+// means no suggestions.
+func (p *Provider) suggestLocations(ctx context.Context, location string) []string {
+	name, _, _ := strings.Cut(location, ",")
+	name = strings.TrimSpace(name)
+	prefix := []rune(name)
+	if len(prefix) < 6 {
+		// Too short to shorten and still find anything relevant.
+		return nil
+	}
+	prefix = prefix[:len(prefix)/2]
+
+	var data GeocodingResponse
+	searchURL := fmt.Sprintf("%s/v1/search?name=%s&count=20&language=en&format=json",
+		p.geocodingBase, url.QueryEscape(string(prefix)))
+	if _, err := p.fetchData(ctx, "suggest", searchURL, &data); err != nil {
+		if p.debugMode {
+			fmt.Printf("Debug suggestLocations: %v\n", err)
+		}
+		return nil
+	}
+
+	data.Results = p.populatedPlaces(data.Results)
+	target := strings.ToLower(name)
+	sort.SliceStable(data.Results, func(i, j int) bool {
+		return editDistance(strings.ToLower(data.Results[i].Name), target) <
+			editDistance(strings.ToLower(data.Results[j].Name), target)
+	})
+	return suggestionNames(data.Results)
+}
+
+// suggestionNames returns the distinct display names of the first results,
+// up to maxSuggestions.
+func suggestionNames(results []GeocodingResult) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, r := range results {
+		name := displayName(r)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		if len(names) == maxSuggestions {
+			break
+		}
+	}
+	return names
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
 			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
 		}
-		return nil, fmt.Errorf("location not found: %s", location)
+		prev = cur
 	}
+	return prev[len(rb)]
+}
 
-	return &data.Results[0], nil
+// geocodingURL returns the geocoding search URL for q. The geocoder takes
+// only a name, so any state or country is left for the caller to filter the
+// results by.
+func (p *Provider) geocodingURL(q weather.LocationQuery) string {
+	var name string
+	var count int
+	switch q.Kind {
+	case weather.LocationZip:
+		name, count = q.Zip, 1
+	case weather.LocationCityState, weather.LocationCityCountry:
+		name, count = q.City, 10
+	default:
+		name = q.Input
+	}
+
+	return fmt.Sprintf("%s/v1/search?name=%s&count=%d&language=en&format=json",
+		p.geocodingBase, url.QueryEscape(name), count)
 }
 
-func New(debugMode bool) *Provider {
-	return &Provider{debugMode: debugMode}
+// rainHours is how many hours of precipitation forecast are requested, for
+// CurrentWeather.NextRain.
+const rainHours = 6
+
+// weatherURL returns the URL for the current conditions, or the forecast if
+// forecast is set. The coordinates are strings so that RequestURLs can show
+// placeholders for ones that aren't known until after geocoding.
+func (p *Provider) weatherURL(lat, lon string, forecast bool) string {
+	var quarterHours string
+	if p.quarterHourly {
+		quarterHours = fmt.Sprintf("&minutely_15=precipitation&forecast_minutely_15=%d", rainHours*4)
+	}
+	if forecast {
+		// Request today too, which the forecast leaves out.
+		return fmt.Sprintf("%s/v1/forecast?latitude=%s&longitude=%s&daily=weathercode,temperature_2m_max,temperature_2m_min,windspeed_10m_max,winddirection_10m_dominant,relative_humidity_2m_max,precipitation_probability_max,cloud_cover_mean,snowfall_sum,sunrise,sunset&current=temperature_2m,relativehumidity_2m,weathercode,windspeed_10m,winddirection_10m,cloud_cover,pressure_msl&hourly=precipitation,precipitation_probability&forecast_hours=%d&temperature_unit=fahrenheit&wind_speed_unit=mph&precipitation_unit=inch&timezone=auto&forecast_days=%d%s",
+			p.forecastBase, lat, lon, rainHours, p.forecastDays+1, quarterHours)
+	}
+	return fmt.Sprintf("%s/v1/forecast?latitude=%s&longitude=%s&current=temperature_2m,relativehumidity_2m,weathercode,windspeed_10m,winddirection_10m,cloud_cover,pressure_msl&hourly=precipitation,precipitation_probability&forecast_hours=%d&temperature_unit=fahrenheit&wind_speed_unit=mph&precipitation_unit=inch&timezone=auto&forecast_days=1&daily=temperature_2m_max,temperature_2m_min,precipitation_probability_max,sunrise,sunset%s",
+		p.forecastBase, lat, lon, rainHours, quarterHours)
+}
+
+// RequestURLs returns the URLs GetCurrentWeather, or GetForecast if forecast
+// is set, would request for location, without making any requests. Unless
+// location is coordinates, the weather URL has placeholders for the geocoded
+// latitude and longitude.
+func (p *Provider) RequestURLs(location string, forecast bool) []string {
+	q, _ := weather.ParseLocation(location)
+	if q.Kind == weather.LocationCoordinates {
+		return []string{p.weatherURL(fmt.Sprintf("%f", q.Lat), fmt.Sprintf("%f", q.Lng), forecast)}
+	}
+
+	return []string{p.geocodingURL(q), p.weatherURL("{latitude}", "{longitude}", forecast)}
+}
+
+func New(debugMode bool, opts ...Option) *Provider {
+	p := &Provider{
+		debugMode:     debugMode,
+		geocodingBase: defaultGeocodingURL,
+		forecastBase:  defaultForecastURL,
+		forecastDays:  DefaultForecastDays,
+		archiveBase:   defaultArchiveURL,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *Provider) Attribution() string {
+	return "Weather data by Open-Meteo.com (CC BY 4.0)"
 }
 
 func (p *Provider) GetCurrentWeather(location string) (*weather.CurrentWeather, error) {
-	coords, err := p.getCoordinates(location)
+	return p.GetCurrentWeatherContext(context.Background(), location)
+}
+
+func (p *Provider) GetForecast(location string) (*weather.Forecast, error) {
+	return p.GetForecastContext(context.Background(), location)
+}
+
+// GetCurrentWeatherContext is GetCurrentWeather with its requests made with
+// ctx.
+func (p *Provider) GetCurrentWeatherContext(ctx context.Context, location string) (*weather.CurrentWeather, error) {
+	if err := weather.CheckLocation(location); err != nil {
+		return nil, err
+	}
+	coords, err := p.getCoordinates(ctx, location)
 	if err != nil {
 		return nil, err
 	}
 
-	url := fmt.Sprintf("https://api.open-meteo.com/v1/forecast?latitude=%f&longitude=%f&current=temperature_2m,relativehumidity_2m,weathercode,windspeed_10m&temperature_unit=fahrenheit&timezone=auto&forecast_days=1&daily=temperature_2m_max,temperature_2m_min",
-		coords.Latitude, coords.Longitude)
+	url := p.weatherURL(fmt.Sprintf("%f", coords.Latitude), fmt.Sprintf("%f", coords.Longitude), false)
 	if p.debugMode {
 		fmt.Printf("Debug GetCurrentWeather URL: %s\n", url)
 	}
 
 	var data WeatherResponse
-	if err := p.fetchData(url, &data); err != nil {
+	cachedAt, err := p.fetchData(ctx, "current", url, &data)
+	if err != nil {
 		return nil, err
 	}
 	if p.debugMode {
 		fmt.Printf("Debug GetCurrentWeather data: %+v\n", data)
 	}
 
-	var highTemp, lowTemp float64
-	if len(data.Daily.TempMax) > 0 && len(data.Daily.TempMin) > 0 {
-		highTemp = data.Daily.TempMax[0]
-		lowTemp = data.Daily.TempMin[0]
-	}
-
-	return &weather.CurrentWeather{
-		Location:    coords.Name,
-		Conditions:  p.getWeatherDescription(data.CurrentWeather.WeatherCode),
-		Temperature: data.CurrentWeather.Temperature,
-		FeelsLike:   data.CurrentWeather.Temperature,
-		Humidity:    data.CurrentWeather.RelativeHumidity,
-		WindSpeed:   data.CurrentWeather.WindSpeed,
-		TempMax:     highTemp,
-		TempMin:     lowTemp,
-	}, nil
+	return p.currentFromResponse(coords.Name, &data, cachedAt), nil
 }
 
-func (p *Provider) GetForecast(location string) (*weather.Forecast, error) {
-	coords, err := p.getCoordinates(location)
+// GetForecastContext is GetForecast with its requests made with ctx.
+func (p *Provider) GetForecastContext(ctx context.Context, location string) (*weather.Forecast, error) {
+	if err := weather.CheckLocation(location); err != nil {
+		return nil, err
+	}
+	coords, err := p.getCoordinates(ctx, location)
 	if err != nil {
 		return nil, err
 	}
 
-	// Request 6 days to get enough data (today + 5 future days)
-	url := fmt.Sprintf("https://api.open-meteo.com/v1/forecast?latitude=%f&longitude=%f&daily=weathercode,temperature_2m_max,temperature_2m_min,windspeed_10m_max,relative_humidity_2m_max&current=temperature_2m,relativehumidity_2m,weathercode,windspeed_10m&temperature_unit=fahrenheit&timezone=auto&forecast_days=6",
-		coords.Latitude, coords.Longitude)
+	url := p.weatherURL(fmt.Sprintf("%f", coords.Latitude), fmt.Sprintf("%f", coords.Longitude), true)
 
 	if p.debugMode {
 		fmt.Printf("Debug GetForecast URL: %s\n", url)
 	}
 
 	var data WeatherResponse
-	if err := p.fetchData(url, &data); err != nil {
+	cachedAt, err := p.fetchData(ctx, "forecast", url, &data)
+	if err != nil {
 		return nil, err
 	}
 
-	if len(data.Daily.Time) < 2 {
+	// The API can return fewer days than requested, and any daily array
+	// can be shorter than the dates, or missing. Each day is made from what
+	// there is: a missing weather code is an unknown condition, and missing
+	// temperatures and wind speeds are NaN until Sanitize marks them
+	// unavailable. Today, and any days before it, are skipped.
+	today := data.todayIndex(time.Now())
+	days := min(len(data.Daily.Time)-today-1, p.forecastDays)
+	if days < 1 {
 		return nil, fmt.Errorf("insufficient forecast data available")
 	}
 
-	dailyItems := make([]weather.DailyForecast, 5)
-	for i := 0; i < 5; i++ {
-		sourceIdx := i + 1 // Skip the first, current, day
+	dailyItems := make([]weather.DailyForecast, days)
+	for i := 0; i < days; i++ {
+		sourceIdx := today + 1 + i
 		date, _ := time.Parse("2006-01-02", data.Daily.Time[sourceIdx])
+		code := dailyValue(data.Daily.WeatherCode, sourceIdx, noWeatherCode)
+		high := dailyValue(data.Daily.TempMax, sourceIdx, math.NaN())
 		dailyItems[i] = weather.DailyForecast{
-			Date:       date,
-			Conditions: p.getWeatherDescription(data.Daily.WeatherCode[sourceIdx]),
-			High:       data.Daily.TempMax[sourceIdx],
-			Low:        data.Daily.TempMin[sourceIdx],
-			WindSpeed:  data.Daily.WindSpeed[sourceIdx],
-			Humidity:   data.Daily.RelativeHumidity[sourceIdx],
+			Date:              date,
+			Conditions:        withCloudCover(p.getWeatherDescription(code), code, dailyValue(data.Daily.CloudCover, sourceIdx, nil)),
+			WeatherCode:       code,
+			Condition:         conditionFromCode(code),
+			PrecipType:        weather.DetectPrecipType(conditionFromCode(code), high),
+			High:              high,
+			Low:               dailyValue(data.Daily.TempMin, sourceIdx, math.NaN()),
+			WindSpeed:         dailyValue(data.Daily.WindSpeed, sourceIdx, math.NaN()),
+			WindDirection:     dailyValue(data.Daily.WindDirection, sourceIdx, 0),
+			Humidity:          dailyValue(data.Daily.RelativeHumidity, sourceIdx, 0),
+			PrecipProbability: dailyValue(data.Daily.PrecipProbability, sourceIdx, 0),
+			Snowfall:          dailyValue(data.Daily.Snowfall, sourceIdx, 0),
 		}
 	}
 
+	forecast := &weather.Forecast{
+		Location:   coords.Name,
+		Current:    p.currentFromResponse(coords.Name, &data, cachedAt),
+		DailyItems: dailyItems,
+		CachedAt:   cachedAt,
+	}
+	forecast.Sanitize()
+	return forecast, nil
+}
+
+// currentFromResponse builds the current conditions from a forecast
+// response, taking today's high/low and sun times from today's daily entry.
+func (p *Provider) currentFromResponse(name string, data *WeatherResponse, cachedAt time.Time) *weather.CurrentWeather {
+	today := data.todayIndex(time.Now())
+
 	var highTemp, lowTemp float64
-	if len(data.Daily.TempMax) > 0 && len(data.Daily.TempMin) > 0 {
-		highTemp = data.Daily.TempMax[0]
-		lowTemp = data.Daily.TempMin[0]
+	if len(data.Daily.TempMax) > today && len(data.Daily.TempMin) > today {
+		highTemp = data.Daily.TempMax[today]
+		lowTemp = data.Daily.TempMin[today]
 	}
 
-	current := &weather.CurrentWeather{
-		Location:    coords.Name,
-		Conditions:  p.getWeatherDescription(data.CurrentWeather.WeatherCode),
-		Temperature: data.CurrentWeather.Temperature,
-		FeelsLike:   data.CurrentWeather.Temperature,
-		Humidity:    data.CurrentWeather.RelativeHumidity,
-		WindSpeed:   data.CurrentWeather.WindSpeed,
-		TempMax:     highTemp,
-		TempMin:     lowTemp,
+	var precipProbability int
+	if len(data.Daily.PrecipProbability) > today {
+		precipProbability = data.Daily.PrecipProbability[today]
 	}
 
-	return &weather.Forecast{
-		Location:   coords.Name,
-		Current:    current,
-		DailyItems: dailyItems,
-	}, nil
+	var sunrise, sunset time.Time
+	if len(data.Daily.Sunrise) > today && len(data.Daily.Sunset) > today {
+		loc := data.location()
+		sunrise = parseLocalTime(data.Daily.Sunrise[today], loc)
+		sunset = parseLocalTime(data.Daily.Sunset[today], loc)
+	}
+	// Polar day and night are reported with sunrise equal to sunset.
+	if sunrise.Equal(sunset) {
+		sunrise, sunset = time.Time{}, time.Time{}
+	}
+
+	w := &weather.CurrentWeather{
+		Location:          name,
+		Conditions:        withCloudCover(p.getWeatherDescription(data.CurrentWeather.WeatherCode), data.CurrentWeather.WeatherCode, data.CurrentWeather.CloudCover),
+		WeatherCode:       data.CurrentWeather.WeatherCode,
+		Condition:         conditionFromCode(data.CurrentWeather.WeatherCode),
+		PrecipType:        weather.DetectPrecipType(conditionFromCode(data.CurrentWeather.WeatherCode), data.CurrentWeather.Temperature),
+		Temperature:       data.CurrentWeather.Temperature,
+		FeelsLike:         data.CurrentWeather.Temperature,
+		Humidity:          data.CurrentWeather.RelativeHumidity,
+		WindSpeed:         data.CurrentWeather.WindSpeed,
+		WindDirection:     data.CurrentWeather.WindDirection,
+		TempMax:           highTemp,
+		TempMin:           lowTemp,
+		PrecipProbability: precipProbability,
+		Pressure:          weather.HpaToInHg(data.CurrentWeather.Pressure),
+		ObservedAt:        parseLocalTime(data.CurrentWeather.Time, data.location()),
+		Sunrise:           sunrise,
+		Sunset:            sunset,
+		TimeZone:          data.Timezone,
+		Elevation:         data.Elevation,
+		Hourly:            hourlyPrecip(data),
+		Latitude:          data.Latitude,
+		Longitude:         data.Longitude,
+		CachedAt:          cachedAt,
+	}
+	w.Sanitize()
+	return w
+}
+
+// todayIndex returns the index of the daily entry for now's date in the
+// location's time zone. It's usually the first, but a response cached before
+// local midnight starts on what is by now yesterday. If no entry has today's
+// date, the first is taken to be today.
+func (data *WeatherResponse) todayIndex(now time.Time) int {
+	today := now.In(data.location()).Format("2006-01-02")
+	for i, date := range data.Daily.Time {
+		if date == today {
+			return i
+		}
+	}
+	return 0
+}
+
+// hourlyPrecip returns the precipitation forecast in data, in 15-minute
+// steps if it has them for every step, or else hourly, up to as
+// many hours as both its arrays have.
+func hourlyPrecip(data *WeatherResponse) []weather.HourlyPrecip {
+	n := min(len(data.Hourly.Time), len(data.Hourly.Precipitation), len(data.Hourly.PrecipProbability))
+	if n == 0 {
+		return nil
+	}
+	loc := data.location()
+	if steps := quarterHourPrecip(data, n, loc); steps != nil {
+		return steps
+	}
+	hours := make([]weather.HourlyPrecip, 0, n)
+	for i := 0; i < n; i++ {
+		t := parseLocalTime(data.Hourly.Time[i], loc)
+		if t.IsZero() {
+			continue
+		}
+		hours = append(hours, weather.HourlyPrecip{
+			Time:          t,
+			Precipitation: data.Hourly.Precipitation[i],
+			Probability:   data.Hourly.PrecipProbability[i],
+		})
+	}
+	return hours
+}
+
+// quarterHourPrecip returns data's 15-minute precipitation over its first n
+// hours, each step with the chance of precipitation of its hour. It returns
+// nil if any step is missing, so the forecast isn't a mix of steps.
+func quarterHourPrecip(data *WeatherResponse, n int, loc *time.Location) []weather.HourlyPrecip {
+	m := &data.Minutely15
+	if len(m.Time) == 0 || len(m.Time) != len(m.Precipitation) {
+		return nil
+	}
+	hours := make([]time.Time, n)
+	for i := range hours {
+		if hours[i] = parseLocalTime(data.Hourly.Time[i], loc); hours[i].IsZero() {
+			return nil
+		}
+	}
+
+	var steps []weather.HourlyPrecip
+	for i, ts := range m.Time {
+		t := parseLocalTime(ts, loc)
+		if t.IsZero() || m.Precipitation[i] == nil {
+			return nil
+		}
+		// The steps can start within the current hour, and run past the
+		// hours asked for.
+		h := sort.Search(n, func(h int) bool { return hours[h].Add(time.Hour).After(t) })
+		if h == n {
+			break
+		}
+		if t.Before(hours[h]) {
+			continue
+		}
+		steps = append(steps, weather.HourlyPrecip{
+			Time:          t,
+			Precipitation: *m.Precipitation[i],
+			Probability:   data.Hourly.PrecipProbability[h],
+		})
+	}
+	return steps
+}
+
+// location returns the time zone of a response requested with timezone=auto:
+// the IANA zone if the system knows it, or else the UTC offset under the
+// zone's abbreviation.
+func (data *WeatherResponse) location() *time.Location {
+	if data.Timezone != "" {
+		if loc, err := time.LoadLocation(data.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.FixedZone(data.TimezoneAbbreviation, data.UTCOffsetSeconds)
 }
 
-func (p *Provider) fetchData(url string, target interface{}) error {
+// localTimeLayouts are the forms of time in responses, most usual first:
+// without seconds or a zone, as documented, and with either or both.
+var localTimeLayouts = []string{
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	time.RFC3339,
+}
+
+// parseLocalTime parses a time such as "2025-02-15T06:45" from a response
+// requested with timezone=auto, which is local to the location and has no
+// zone of its own. A time that does have a zone is converted to loc. It
+// returns the zero time if s can't be parsed.
+func parseLocalTime(s string, loc *time.Location) time.Time {
+	for _, layout := range localTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.In(loc)
+		}
+	}
+	return time.Time{}
+}
+
+// fetchData decodes the response for url, from the named endpoint, into
+// target. The returned time is when the response was cached, or the zero
+// time if it was fetched live (or replayed from fixtures).
+func (p *Provider) fetchData(ctx context.Context, endpoint, url string, target interface{}) (time.Time, error) {
+	return p.fetchCached(ctx, endpoint, url, target, p.cache, p.cacheTTL)
+}
+
+// fetchCached is fetchData with the response cached in c, if not nil, for
+// ttl.
+func (p *Provider) fetchCached(ctx context.Context, endpoint, url string, target interface{}, c weather.Cache, ttl time.Duration) (time.Time, error) {
+	var r response
+	if p.fixtures.Replaying() {
+		body, err := p.fixtures.Load("openmeteo", endpoint)
+		if err != nil {
+			return time.Time{}, err
+		}
+		r.body = body
+	} else {
+		// Concurrent requests for the same URL, as a batch can make, share
+		// one cache lookup and fetch, made with the first one's ctx.
+		v, err, _ := p.flight.Do(url, func() (interface{}, error) {
+			body, cachedAt, err := p.fetchBody(ctx, url, c, ttl)
+			if err == nil && p.fixtures.Recording() {
+				err = p.fixtures.Save("openmeteo", endpoint, body)
+			}
+			return response{body, cachedAt}, err
+		})
+		if err != nil {
+			return time.Time{}, err
+		}
+		r = v.(response)
+	}
+
+	if err := json.Unmarshal(r.body, target); err != nil {
+		return time.Time{}, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	return r.cachedAt, nil
+}
+
+// response is a response body and when it was cached, as for fetchData.
+type response struct {
+	body     []byte
+	cachedAt time.Time
+}
+
+// fetchBody returns the JSON response body for url from c, if it's there,
+// or else from the API, caching it in c for ttl.
+func (p *Provider) fetchBody(ctx context.Context, url string, c weather.Cache, ttl time.Duration) ([]byte, time.Time, error) {
 	if p.debugMode {
 		fmt.Printf("Debug fetchData URL: %s\n", url)
 	}
 
-	resp, err := http.Get(url)
+	start := time.Now()
+	if c != nil {
+		if body, cachedAt, ok := weather.GetCached(c, url); ok && json.Valid(body) {
+			if p.debugMode {
+				fmt.Printf("Debug fetchData cache hit from %s\n", cachedAt.Format(time.RFC3339))
+			}
+			p.recordTiming(url, start, true)
+			return body, cachedAt, nil
+		}
+	}
+
+	resp, err := weather.GetWithRetry(ctx, url)
+	p.recordTiming(url, start, false)
 	if err != nil {
-		return fmt.Errorf("error making request: %v", err)
+		return nil, time.Time{}, fmt.Errorf("error making request: %v", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("error reading response: %v", err)
+		return nil, time.Time{}, fmt.Errorf("error reading response: %v", err)
 	}
 	if p.debugMode {
 		fmt.Printf("Debug fetchData response: %s\n", string(body))
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API error: %s", string(body))
+		// Open-Meteo reports bad parameters as JSON with a reason; fall back
+		// to the raw body for anything else (e.g. a proxy error page).
+		var apiErr ErrorResponse
+		if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error && apiErr.Reason != "" {
+			return nil, time.Time{}, fmt.Errorf("API error: %s", apiErr.Reason)
+		}
+		return nil, time.Time{}, fmt.Errorf("API error: %s", string(body))
 	}
 
-	if err := json.Unmarshal(body, target); err != nil {
-		return fmt.Errorf("error parsing JSON: %v", err)
+	if c != nil && json.Valid(body) {
+		weather.SetCached(c, url, body, ttl)
 	}
 
-	return nil
+	return body, time.Time{}, nil
+}
+
+// requestNames names each API, by the last element of its path, for
+// timings.
+var requestNames = map[string]string{
+	"search":   "geocode",
+	"forecast": "forecast",
+	"archive":  "archive",
+}
+
+func (p *Provider) recordTiming(rawURL string, start time.Time, cached bool) {
+	if p.timing == nil {
+		return
+	}
+	name := "request"
+	if u, err := url.Parse(rawURL); err == nil {
+		if n, ok := requestNames[path.Base(u.Path)]; ok {
+			name = n
+		}
+	}
+	p.timing(weather.RequestTiming{Name: name, URL: rawURL, Duration: time.Since(start), Cached: cached})
 }
 
 func (p *Provider) getWeatherDescription(code int) string {
@@ -315,63 +1035,77 @@ func (p *Provider) getWeatherDescription(code int) string {
 	return "unknown"
 }
 
-func matchedState(fullName, abbrev string) bool {
-	stateMap := map[string]string{
-		"Alabama":        "AL",
-		"Alaska":         "AK",
-		"Arizona":        "AZ",
-		"Arkansas":       "AR",
-		"California":     "CA",
-		"Colorado":       "CO",
-		"Connecticut":    "CT",
-		"Delaware":       "DE",
-		"Florida":        "FL",
-		"Georgia":        "GA",
-		"Hawaii":         "HI",
-		"Idaho":          "ID",
-		"Illinois":       "IL",
-		"Indiana":        "IN",
-		"Iowa":           "IA",
-		"Kansas":         "KS",
-		"Kentucky":       "KY",
-		"Louisiana":      "LA",
-		"Maine":          "ME",
-		"Maryland":       "MD",
-		"Massachusetts":  "MA",
-		"Michigan":       "MI",
-		"Minnesota":      "MN",
-		"Mississippi":    "MS",
-		"Missouri":       "MO",
-		"Montana":        "MT",
-		"Nebraska":       "NE",
-		"Nevada":         "NV",
-		"New Hampshire":  "NH",
-		"New Jersey":     "NJ",
-		"New Mexico":     "NM",
-		"New York":       "NY",
-		"North Carolina": "NC",
-		"North Dakota":   "ND",
-		"Ohio":           "OH",
-		"Oklahoma":       "OK",
-		"Oregon":         "OR",
-		"Pennsylvania":   "PA",
-		"Rhode Island":   "RI",
-		"South Carolina": "SC",
-		"South Dakota":   "SD",
-		"Tennessee":      "TN",
-		"Texas":          "TX",
-		"Utah":           "UT",
-		"Vermont":        "VT",
-		"Virginia":       "VA",
-		"Washington":     "WA",
-		"West Virginia":  "WV",
-		"Wisconsin":      "WI",
-		"Wyoming":        "WY",
-	}
-
-	if abbr, ok := stateMap[fullName]; ok {
+// noWeatherCode stands in for a weather code the response doesn't have. It
+// isn't a WMO code, so it's described as unknown and is ConditionUnknown.
+const noWeatherCode = -1
+
+// dailyValue returns values[i], or missing if the daily array is too short to
+// have it.
+func dailyValue[T any](values []T, i int, missing T) T {
+	if i < len(values) {
+		return values[i]
+	}
+	return missing
+}
+
+// withCloudCover adds the cloud cover percentage to the description of a
+// mainly clear, partly cloudy or overcast sky (WMO codes 1 to 3), which each
+// span a range of cover, as in "partly cloudy (45%)". Other descriptions, and
+// any without a cover, are left alone.
+func withCloudCover(desc string, code int, cover *float64) string {
+	if cover == nil || code < 1 || code > 3 {
+		return desc
+	}
+	return fmt.Sprintf("%s (%.0f%%)", desc, *cover)
+}
+
+// conditionFromCode classifies a WMO weather code.
+func conditionFromCode(code int) weather.Condition {
+	switch code {
+	case 0, 1:
+		return weather.ConditionClear
+	case 2:
+		return weather.ConditionPartlyCloudy
+	case 3:
+		return weather.ConditionCloudy
+	case 45, 48:
+		return weather.ConditionFog
+	case 51, 53, 55:
+		return weather.ConditionDrizzle
+	case 56, 57, 66, 67:
+		return weather.ConditionFreezingRain
+	case 61, 63, 65, 80, 81, 82:
+		return weather.ConditionRain
+	case 71, 73, 75, 77, 85, 86:
+		return weather.ConditionSnow
+	case 95, 96, 99:
+		return weather.ConditionThunderstorm
+	}
+	return weather.ConditionUnknown
+}
+
+// matchedState reports whether result is in the state abbreviated abbrev.
+// Territories are countries to the geocoder, so a result is in one by its
+// country code.
+func matchedState(result GeocodingResult, abbrev string) bool {
+	if weather.Territory(abbrev) {
+		return strings.EqualFold(result.CountryCode, abbrev)
+	}
+	if abbr, ok := weather.StateAbbreviation(result.State); ok {
 		return abbr == abbrev
 	}
 
 	return false
 }
+
+// displayName formats a geocoding result as a suggestion for the user, such
+// as "Springfield, IL", or "Paris, France" outside the US.
+func displayName(r GeocodingResult) string {
+	if abbr, ok := weather.StateAbbreviation(r.State); ok {
+		return fmt.Sprintf("%s, %s", r.Name, abbr)
+	}
+	if r.Country != "" {
+		return fmt.Sprintf("%s, %s", r.Name, r.Country)
+	}
+	return r.Name
+}
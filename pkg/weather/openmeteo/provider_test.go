@@ -0,0 +1,210 @@
+package openmeteo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// fixtureServer serves the recorded responses in testdata as the geocoding
+// and forecast APIs would. Weather requests must be for the coordinates
+// geocoding gave, and in the units the provider converts from.
+func fixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		serveFixture(t, w, "geocode.json")
+	})
+	mux.HandleFunc("/v1/forecast", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("latitude") != "39.801720" || q.Get("longitude") != "-89.643710" {
+			t.Errorf("weather requested for %s,%s, want the geocoded 39.801720,-89.643710", q.Get("latitude"), q.Get("longitude"))
+		}
+		for param, want := range map[string]string{
+			"temperature_unit":   "fahrenheit",
+			"wind_speed_unit":    "mph",
+			"precipitation_unit": "inch",
+		} {
+			if got := q.Get(param); got != want {
+				t.Errorf("%s = %q, want %q", param, got, want)
+			}
+		}
+		if strings.Contains(q.Get("daily"), "weathercode") {
+			serveFixture(t, w, "forecast.json")
+		} else {
+			serveFixture(t, w, "current.json")
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func serveFixture(t *testing.T, w http.ResponseWriter, name string) {
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func TestGetCurrentWeather(t *testing.T) {
+	srv := fixtureServer(t)
+	p := New(false, WithBaseURL(srv.URL))
+
+	w, err := p.GetCurrentWeather("Springfield, IL")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chicago, _ := time.LoadLocation("America/Chicago")
+	want := weather.CurrentWeather{
+		Location:          "Springfield",
+		Conditions:        "slight rain",
+		WeatherCode:       61,
+		Condition:         weather.ConditionRain,
+		PrecipType:        weather.PrecipRain,
+		Temperature:       41.5,
+		FeelsLike:         41.5,
+		TempMax:           45.1,
+		TempMin:           33.8,
+		Humidity:          72,
+		WindSpeed:         14.2,
+		WindDirection:     225,
+		PrecipProbability: 90,
+		Pressure:          weather.HpaToInHg(1012.5),
+		ObservedAt:        time.Date(2025, 3, 1, 12, 0, 0, 0, chicago),
+		Sunrise:           time.Date(2025, 3, 1, 6, 25, 0, 0, chicago),
+		Sunset:            time.Date(2025, 3, 1, 17, 46, 0, 0, chicago),
+		TimeZone:          "America/Chicago",
+		Elevation:         180,
+		Latitude:          39.8,
+		Longitude:         -89.64,
+	}
+	got := *w
+	got.Hourly = nil
+	if !got.Equal(&want) {
+		t.Errorf("GetCurrentWeather =\n%+v\nwant\n%+v", got, want)
+	}
+	if len(w.Hourly) != 2 || w.Hourly[1].Precipitation != 0.05 || w.Hourly[1].Probability != 90 {
+		t.Errorf("Hourly = %+v, want 2 hours ending with 0.05 in at 90%%", w.Hourly)
+	}
+}
+
+func TestGetForecast(t *testing.T) {
+	srv := fixtureServer(t)
+	p := New(false, WithBaseURL(srv.URL))
+
+	f, err := p.GetForecast("Springfield, IL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Location != "Springfield" {
+		t.Errorf("Location = %q, want Springfield", f.Location)
+	}
+	if f.Current == nil || f.Current.Temperature != 41.5 {
+		t.Errorf("Current = %+v, want 41.5°F", f.Current)
+	}
+
+	// The first daily entry is today, which Current covers, so the days
+	// start from the second.
+	date := func(day int) time.Time { return time.Date(2025, 3, day, 0, 0, 0, 0, time.UTC) }
+	want := []weather.DailyForecast{
+		{Date: date(2), Conditions: "moderate snow", WeatherCode: 73, Condition: weather.ConditionSnow, PrecipType: weather.PrecipSnow,
+			High: 31.2, Low: 22.5, WindSpeed: 24.9, WindDirection: 340, Humidity: 88, PrecipProbability: 75, Snowfall: 2.4},
+		{Date: date(3), Conditions: "overcast (80%)", WeatherCode: 3, Condition: weather.ConditionCloudy,
+			High: 38.0, Low: 20.1, WindSpeed: 9.3, WindDirection: 300, Humidity: 70, PrecipProbability: 10},
+		{Date: date(4), Conditions: "clear sky", WeatherCode: 0, Condition: weather.ConditionClear,
+			High: 50.4, Low: 30.9, WindSpeed: 6.2, WindDirection: 180, Humidity: 60},
+	}
+	if len(f.DailyItems) != len(want) {
+		t.Fatalf("got %d days, want %d: %+v", len(f.DailyItems), len(want), f.DailyItems)
+	}
+	for i := range want {
+		if got := f.DailyItems[i]; !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("day %d =\n%+v\nwant\n%+v", i+1, got, want[i])
+		}
+	}
+}
+
+func TestGetCurrentWeatherStateNotFound(t *testing.T) {
+	srv := fixtureServer(t)
+	p := New(false, WithBaseURL(srv.URL))
+
+	_, err := p.GetCurrentWeather("Springfield, TX")
+	var notFound *weather.LocationNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("err = %v, want a LocationNotFoundError", err)
+	}
+	// The Springfields in other states are the suggestions, most populous
+	// first.
+	want := []string{"Springfield, MO", "Springfield, MA", "Springfield, IL"}
+	if !reflect.DeepEqual(notFound.Suggestions, want) {
+		t.Errorf("Suggestions = %q, want %q", notFound.Suggestions, want)
+	}
+}
+
+// A request with invalid parameters gets a 400 with the reason as JSON,
+// which is all the error should say.
+func TestAPIErrorReason(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := os.ReadFile(filepath.Join("testdata", "error400.json"))
+		if err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(body)
+	}))
+	defer srv.Close()
+	p := New(false, WithBaseURL(srv.URL))
+
+	_, err := p.GetCurrentWeather("42.36,-71.06")
+	want := "API error: Cannot initialize WeatherVariable from invalid String value tempeature_2m for key hourly"
+	if err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+}
+
+// The wind thresholds, such as ClothingHint's and Beaufort's, are in mph, as
+// are the other providers' speeds, so Open-Meteo must be asked for mph
+// rather than its default km/h.
+func TestWeatherURLSpeedsInMph(t *testing.T) {
+	p := New(false)
+	for _, forecast := range []bool{false, true} {
+		if url := p.weatherURL("42.36", "-71.06", forecast); !strings.Contains(url, "&wind_speed_unit=mph") {
+			t.Errorf("weatherURL(forecast=%v) = %s, want wind_speed_unit=mph", forecast, url)
+		}
+	}
+}
+
+// Territories are countries to the geocoder, so "San Juan, PR" matches by
+// country code; states and DC match by the state.
+func TestMatchedState(t *testing.T) {
+	tests := []struct {
+		result GeocodingResult
+		state  string
+		want   bool
+	}{
+		{GeocodingResult{State: "Illinois", CountryCode: "US"}, "IL", true},
+		{GeocodingResult{State: "Missouri", CountryCode: "US"}, "IL", false},
+		{GeocodingResult{State: "District of Columbia", CountryCode: "US"}, "DC", true},
+		{GeocodingResult{State: "San Juan", CountryCode: "PR"}, "PR", true},
+		{GeocodingResult{State: "San Juan", CountryCode: "AR"}, "PR", false},
+	}
+	for _, tt := range tests {
+		if got := matchedState(tt.result, tt.state); got != tt.want {
+			t.Errorf("matchedState(%s, %s, %s) = %v, want %v", tt.result.State, tt.result.CountryCode, tt.state, got, tt.want)
+		}
+	}
+}
@@ -1,18 +1,24 @@
 package openweather
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
-	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/duluk/weather/pkg/weather"
+	"golang.org/x/sync/singleflight"
 )
 
 /*
@@ -38,6 +44,7 @@ type WeatherData struct {
 	} `json:"coord"`
 	Base    string `json:"base"`
 	Weather []struct {
+		ID          int    `json:"id"`
 		Description string `json:"description"`
 	} `json:"weather"`
 	Main struct {
@@ -59,12 +66,40 @@ type WeatherData struct {
 		Percentage int `json:"all"`
 	} `json:"clouds"`
 	Sys struct {
-		Sunrise int64 `json:"sunrise"`
-		Sunset  int64 `json:"sunset"`
+		Sunrise int64  `json:"sunrise"`
+		Sunset  int64  `json:"sunset"`
+		Country string `json:"country"`
 	} `json:"sys"`
-	Visibility int    `json:"visibility"`
-	Name       string `json:"name"`
-	RespCode   int    `json:"cod"`
+	Rain       *Volume `json:"rain,omitempty"`
+	Snow       *Volume `json:"snow,omitempty"`
+	Visibility int     `json:"visibility"`
+	Name       string  `json:"name"`
+	RespCode   Code    `json:"cod"`
+}
+
+// Volume is the "rain" or "snow" object of a response, in mm over the last
+// hour or three. The objects are left out when there's none.
+type Volume struct {
+	OneHour   float64 `json:"1h"`
+	ThreeHour float64 `json:"3h"`
+}
+
+// Code is the "cod" status of a response, which OpenWeather sends as a number
+// from some endpoints, such as 200, and a string from others, such as "200".
+type Code int
+
+func (c *Code) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*c = 0
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("invalid response code %s", data)
+	}
+	*c = Code(n)
+	return nil
 }
 
 type ForecastData struct {
@@ -83,6 +118,7 @@ type ForecastData struct {
 			TempKf      float64 `json:"temp_kf"`
 		} `json:"main"`
 		Weather []struct {
+			ID          int    `json:"id"`
 			Description string `json:"description"`
 		} `json:"weather"`
 		Clouds struct {
@@ -93,8 +129,11 @@ type ForecastData struct {
 			Gust  float64 `json:"gust"`
 			Deg   int     `json:"deg"`
 		} `json:"wind"`
-		DateText   string `json:"dt_txt"`
-		Visibility int    `json:"visibility"`
+		DateText   string  `json:"dt_txt"`
+		Visibility int     `json:"visibility"`
+		Pop        float64 `json:"pop"`
+		Rain       *Volume `json:"rain,omitempty"`
+		Snow       *Volume `json:"snow,omitempty"`
 	} `json:"list"`
 	City struct {
 		Name        string `json:"name"`
@@ -108,26 +147,254 @@ type ForecastData struct {
 		Sunrise    int64  `json:"sunrise"`
 		Sunset     int64  `json:"sunset"`
 	} `json:"city"`
-	RespCode string `json:"cod"`
+	RespCode Code `json:"cod"`
+}
+
+// DailyForecastData is the response from the daily forecast endpoint
+// (forecast/daily), which is only available on paid plans.
+type DailyForecastData struct {
+	Count int `json:"cnt"`
+	List  []struct {
+		DateTime int64 `json:"dt"`
+		Sunrise  int64 `json:"sunrise"`
+		Sunset   int64 `json:"sunset"`
+		Temp     struct {
+			Day   float64 `json:"day"`
+			Min   float64 `json:"min"`
+			Max   float64 `json:"max"`
+			Night float64 `json:"night"`
+			Eve   float64 `json:"eve"`
+			Morn  float64 `json:"morn"`
+		} `json:"temp"`
+		Pressure int `json:"pressure"`
+		Humidity int `json:"humidity"`
+		Weather  []struct {
+			ID          int    `json:"id"`
+			Description string `json:"description"`
+		} `json:"weather"`
+		Speed  float64 `json:"speed"`
+		Deg    int     `json:"deg"`
+		Gust   float64 `json:"gust"`
+		Clouds int     `json:"clouds"`
+		Pop    float64 `json:"pop"`
+		// Rain and Snow are the day's totals in mm, absent if none.
+		Rain float64 `json:"rain,omitempty"`
+		Snow float64 `json:"snow,omitempty"`
+	} `json:"list"`
+	City struct {
+		Name     string `json:"name"`
+		Country  string `json:"country"`
+		TimeZone int    `json:"timezone"`
+	} `json:"city"`
+	RespCode Code `json:"cod"`
+}
+
+// ReverseGeocodingData is one result from the reverse geocoding endpoint,
+// which returns a list of these.
+type ReverseGeocodingData struct {
+	Name    string  `json:"name"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+	Country string  `json:"country"`
+	State   string  `json:"state"`
+}
+
+// The number of days requested from the daily forecast endpoint; 16 is the
+// most the API allows.
+const dailyForecastDays = 16
+
+// apiError is returned by fetchData for a non-200 response so callers can
+// react to specific status codes.
+type apiError struct {
+	StatusCode int
+	Body       string
+}
+
+// Error is the message from the JSON error body, such as {"cod":400,
+// "message":"wrong latitude"}, or the whole body if it isn't one.
+func (e *apiError) Error() string {
+	var body struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(e.Body), &body); err == nil && body.Message != "" {
+		return fmt.Sprintf("API error: %s", body.Message)
+	}
+	return fmt.Sprintf("API error: %s", e.Body)
 }
 
 type Provider struct {
-	apiKey      string
-	useTestData bool
+	// keys are the API keys to use in turn, starting with the one given to
+	// New; next counts the requests made, to pick the next one.
+	keys        []string
+	next        atomic.Uint32
+	flight      singleflight.Group
+	fixtures    *weather.Fixtures
 	debugMode   bool
+	cache       weather.Cache
+	cacheTTL    time.Duration
+	useDaily    bool
+	resolveName bool
+	language    string
+	baseURL     string
+	timing      weather.TimingFunc
+	showKey     bool
+	minReadings int
+	// throttle spaces requests out as a key's quota runs low, going by the
+	// rate limits its last response reported, which are in limits.
+	throttle bool
+	limitsMu sync.Mutex
+	limits   map[string]weather.RateLimit
+}
+
+// DefaultMinReadings is how many of a day's six readings from 6am to
+// midnight the 5-day forecast must have for the day to be included. The feed
+// starts and ends partway through a day, and a few readings would make for an
+// unrepresentative high and low.
+const DefaultMinReadings = 4
+
+const defaultBaseURL = "https://api.openweathermap.org"
+
+type Option func(*Provider)
+
+// WithFixtures records each response in f, or replays them from it instead
+// of making requests, as f.Record says. Endpoints are named by their API
+// path: weather, forecast, forecast/daily and reverse.
+func WithFixtures(f *weather.Fixtures) Option {
+	return func(p *Provider) {
+		p.fixtures = f
+	}
+}
+
+// WithBaseURL sends requests to base, such as a local test server or proxy,
+// instead of api.openweathermap.org. The API paths are appended as usual.
+func WithBaseURL(base string) Option {
+	return func(p *Provider) {
+		p.baseURL = strings.TrimSuffix(base, "/")
+	}
+}
+
+// WithCache serves responses from c when present and stores new ones in it
+// for ttl.
+func WithCache(c weather.Cache, ttl time.Duration) Option {
+	return func(p *Provider) {
+		p.cache = c
+		p.cacheTTL = ttl
+	}
+}
+
+// WithDailyForecast makes GetForecast use the 16-day daily forecast endpoint.
+// Accounts without access to it (HTTP 401) fall back to the 5-day forecast.
+func WithDailyForecast() Option {
+	return func(p *Provider) {
+		p.useDaily = true
+	}
+}
+
+// WithAPIKeys adds keys to use along with the one given to New, to spread
+// requests over several keys' quotas. Requests use each key in turn, and one
+// rate limited (429) with a key is retried with the next.
+func WithAPIKeys(keys ...string) Option {
+	return func(p *Provider) {
+		p.keys = append(p.keys, keys...)
+	}
+}
+
+// WithShowKey leaves the API key in the URLs the provider shows: debug
+// output, timings, RequestURLs and ExplainLocation. They're redacted
+// otherwise, so they can be shared safely.
+func WithShowKey() Option {
+	return func(p *Provider) {
+		p.showKey = true
+	}
+}
+
+// WithResolveName looks up a place name for coordinate locations with the
+// reverse geocoding endpoint, at the cost of an extra API call.
+func WithResolveName() Option {
+	return func(p *Provider) {
+		p.resolveName = true
+	}
+}
+
+// WithTiming calls fn with how long each request took. Requests are named
+// by endpoint, e.g. "forecast/daily", except reverse geocoding, "geocode".
+func WithTiming(fn weather.TimingFunc) Option {
+	return func(p *Provider) {
+		p.timing = fn
+	}
+}
+
+// WithLanguage requests weather descriptions in lang, an OpenWeather language
+// code such as "de" or "zh_cn".
+func WithLanguage(lang string) Option {
+	return func(p *Provider) {
+		p.language = lang
+	}
+}
+
+// WithMinReadings sets how many readings a day of the 5-day forecast needs,
+// from 1, which includes every day, to 6; DefaultMinReadings otherwise.
+func WithMinReadings(n int) Option {
+	return func(p *Provider) {
+		p.minReadings = n
+	}
 }
 
-func New(apiKey string, useTestData, debugMode bool) *Provider {
-	return &Provider{
-		apiKey:      apiKey,
-		useTestData: useTestData,
+// WithThrottle slows requests down as an API key's remaining quota, where
+// the plan reports it, runs low, rather than running into rate limiting. See
+// RateLimit.ThrottleDelay.
+func WithThrottle() Option {
+	return func(p *Provider) {
+		p.throttle = true
+	}
+}
+
+func New(apiKey string, debugMode bool, opts ...Option) *Provider {
+	p := &Provider{
+		keys:        []string{apiKey},
 		debugMode:   debugMode,
+		baseURL:     defaultBaseURL,
+		minReadings: DefaultMinReadings,
+	}
+	for _, opt := range opts {
+		opt(p)
 	}
+	return p
+}
+
+func (p *Provider) Attribution() string {
+	return "Weather data by OpenWeather (openweathermap.org)"
+}
+
+// Capabilities reports the optional features OpenWeather supports. The
+// extended forecast needs a paid plan; without one GetForecast falls back to
+// five days. It's only asked for, and so only a week or more, with
+// WithDailyForecast.
+func (p *Provider) Capabilities() weather.Capability {
+	caps := weather.CapExtendedForecast | weather.CapLanguage | weather.CapReverseGeocoding
+	if p.useDaily {
+		caps |= weather.CapWeekForecast
+	}
+	return caps
 }
 
 func (p *Provider) GetCurrentWeather(location string) (*weather.CurrentWeather, error) {
+	return p.GetCurrentWeatherContext(context.Background(), location)
+}
+
+func (p *Provider) GetForecast(location string) (*weather.Forecast, error) {
+	return p.GetForecastContext(context.Background(), location)
+}
+
+// GetCurrentWeatherContext is GetCurrentWeather with its requests made with
+// ctx.
+func (p *Provider) GetCurrentWeatherContext(ctx context.Context, location string) (*weather.CurrentWeather, error) {
+	if err := weather.CheckLocation(location); err != nil {
+		return nil, err
+	}
 	var data WeatherData
-	if err := p.fetchData(location, false, &data); err != nil {
+	cachedAt, err := p.fetchData(ctx, location, "weather", &data)
+	if err != nil {
 		return nil, err
 	}
 
@@ -135,21 +402,51 @@ func (p *Provider) GetCurrentWeather(location string) (*weather.CurrentWeather,
 		return nil, fmt.Errorf("no weather data available")
 	}
 
-	return &weather.CurrentWeather{
-		Location:    data.Name,
-		Conditions:  data.Weather[0].Description,
-		Temperature: data.Main.Temp,
-		FeelsLike:   data.Main.FeelsLike,
-		TempMax:     data.Main.TempMax,
-		TempMin:     data.Main.TempMin,
-		Humidity:    data.Main.Humidity,
-		WindSpeed:   data.Wind.Speed,
-	}, nil
+	w := &weather.CurrentWeather{
+		Location:      p.locationName(ctx, location, data.Name),
+		Conditions:    data.Weather[0].Description,
+		WeatherCode:   data.Weather[0].ID,
+		Condition:     conditionFromID(data.Weather[0].ID),
+		PrecipType:    weather.DetectPrecipType(conditionFromID(data.Weather[0].ID), data.Main.Temp),
+		Temperature:   data.Main.Temp,
+		FeelsLike:     data.Main.FeelsLike,
+		TempMax:       data.Main.TempMax,
+		TempMin:       data.Main.TempMin,
+		Humidity:      data.Main.Humidity,
+		WindSpeed:     data.Wind.Speed,
+		WindDirection: data.Wind.Deg,
+		Precipitation: weather.MmToInches(hourly(data.Rain) + hourly(data.Snow)),
+		Pressure:      weather.HpaToInHg(float64(seaLevel(data.Main.SeaLevel, data.Main.Pressure))),
+		ObservedAt:    localTime(data.DateTime, data.TimeZone),
+		Sunrise:       localTime(data.Sys.Sunrise, data.TimeZone),
+		Sunset:        localTime(data.Sys.Sunset, data.TimeZone),
+		Latitude:      data.Coordinates.Latitude,
+		Longitude:     data.Coordinates.Longitude,
+		CachedAt:      cachedAt,
+	}
+	w.Sanitize()
+	return w, nil
 }
 
-func (p *Provider) GetForecast(location string) (*weather.Forecast, error) {
+// GetForecastContext is GetForecast with its requests made with ctx.
+func (p *Provider) GetForecastContext(ctx context.Context, location string) (*weather.Forecast, error) {
+	if err := weather.CheckLocation(location); err != nil {
+		return nil, err
+	}
+	if p.useDaily {
+		forecast, err := p.getDailyForecast(ctx, location)
+		var apiErr *apiError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+			return forecast, err
+		}
+		if p.debugMode {
+			fmt.Println("Debug GetForecast daily endpoint unauthorized, using 5-day forecast")
+		}
+	}
+
 	var data ForecastData
-	if err := p.fetchData(location, true, &data); err != nil {
+	cachedAt, err := p.fetchData(ctx, location, "forecast", &data)
+	if err != nil {
 		return nil, err
 	}
 
@@ -158,14 +455,149 @@ func (p *Provider) GetForecast(location string) (*weather.Forecast, error) {
 	}
 
 	forecast := &weather.Forecast{
-		Location:   data.City.Name,
+		Location:   p.locationName(ctx, location, data.City.Name),
 		Current:    p.getCurrentFromForecast(&data),
 		DailyItems: p.processForecastData(&data),
+		CachedAt:   cachedAt,
+	}
+	if forecast.Current != nil {
+		forecast.Current.Location = forecast.Location
+		forecast.Current.CachedAt = cachedAt
 	}
+	forecast.Sanitize()
 
 	return forecast, nil
 }
 
+func (p *Provider) getDailyForecast(ctx context.Context, location string) (*weather.Forecast, error) {
+	var data DailyForecastData
+	cachedAt, err := p.fetchData(ctx, location, "forecast/daily", &data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data.List) == 0 {
+		return nil, fmt.Errorf("no forecast data available")
+	}
+
+	dailyItems := make([]weather.DailyForecast, 0, len(data.List))
+	for _, item := range data.List {
+		// dt is midday UTC for the day; shift it by the city's offset so the
+		// date is the local one.
+		local := time.Unix(item.DateTime+int64(data.City.TimeZone), 0).UTC()
+		date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+		var description string
+		var id int
+		if len(item.Weather) > 0 {
+			description = item.Weather[0].Description
+			id = item.Weather[0].ID
+		}
+
+		dailyItems = append(dailyItems, weather.DailyForecast{
+			Date:          date,
+			Conditions:    description,
+			WeatherCode:   id,
+			Condition:     conditionFromID(id),
+			PrecipType:    weather.DetectPrecipType(conditionFromID(id), item.Temp.Max),
+			High:          item.Temp.Max,
+			Low:           item.Temp.Min,
+			WindSpeed:     item.Speed,
+			WindDirection: item.Deg,
+			Humidity:      item.Humidity,
+			// pop is a probability from 0 to 1.
+			PrecipProbability: int(math.Round(item.Pop * 100)),
+			Precipitation:     weather.MmToInches(item.Rain + item.Snow),
+		})
+	}
+
+	// The daily endpoint has no current conditions, so fetch them separately;
+	// the forecast is still useful without them.
+	current, err := p.GetCurrentWeatherContext(ctx, location)
+	if err != nil && p.debugMode {
+		fmt.Printf("Debug getDailyForecast current weather: %v\n", err)
+	}
+
+	forecast := &weather.Forecast{
+		Location:   p.locationName(ctx, location, data.City.Name),
+		Current:    current,
+		DailyItems: dailyItems,
+		CachedAt:   cachedAt,
+	}
+	forecast.Sanitize()
+	return forecast, nil
+}
+
+// locationName returns the place name for location, reverse geocoding it if
+// it's a pair of coordinates and that was requested, or the API returned no
+// name for them. name is the one the API returned with the weather data, used
+// otherwise; if it's empty, and so is any lookup, location itself is used.
+func (p *Provider) locationName(ctx context.Context, location, name string) string {
+	fallback := name
+	if fallback == "" {
+		fallback = location
+	}
+	if !p.resolveName && name != "" {
+		return name
+	}
+	if _, _, ok := weather.ParseCoordinates(location); !ok {
+		return fallback
+	}
+
+	var results []ReverseGeocodingData
+	if _, err := p.fetchData(ctx, location, "reverse", &results); err != nil || len(results) == 0 {
+		if p.debugMode {
+			fmt.Printf("Debug locationName reverse geocoding failed: %v\n", err)
+		}
+		return fallback
+	}
+
+	if results[0].State != "" {
+		return fmt.Sprintf("%s, %s", results[0].Name, results[0].State)
+	}
+	return fmt.Sprintf("%s, %s", results[0].Name, results[0].Country)
+}
+
+// ExplainLocation returns how location is resolved. OpenWeather geocodes the
+// query itself, so this fetches the current weather to see where it went.
+func (p *Provider) ExplainLocation(location string) (*weather.Resolution, error) {
+	ctx := context.Background()
+	if err := weather.CheckLocation(location); err != nil {
+		return nil, err
+	}
+	q, err := weather.ParseLocation(location)
+	if err != nil {
+		return nil, err
+	}
+	res := &weather.Resolution{
+		Input:  location,
+		Form:   q.Kind.String(),
+		Name:   location,
+		State:  q.State,
+		Query:  p.redact(p.buildURL(location, "weather", p.keys[0])),
+		Reason: "OpenWeather's own match for the query",
+	}
+	if q.Kind == weather.LocationCityState || q.Kind == weather.LocationCityCountry {
+		res.Name = q.City
+	}
+	if q.Kind == weather.LocationCoordinates {
+		res.Reason = "coordinates are used as given"
+	}
+
+	var data WeatherData
+	if _, err := p.fetchData(ctx, location, "weather", &data); err != nil {
+		return res, err
+	}
+	res.Candidates = 1
+	res.Chosen = weather.Place{
+		Name:      p.locationName(ctx, location, data.Name),
+		Country:   data.Sys.Country,
+		Latitude:  data.Coordinates.Latitude,
+		Longitude: data.Coordinates.Longitude,
+	}
+	return res, nil
+}
+
 func (p *Provider) getCurrentFromForecast(data *ForecastData) *weather.CurrentWeather {
 	if len(data.List) == 0 || len(data.List[0].Weather) == 0 {
 		return nil
@@ -173,15 +605,68 @@ func (p *Provider) getCurrentFromForecast(data *ForecastData) *weather.CurrentWe
 
 	current := data.List[0]
 	return &weather.CurrentWeather{
-		Location:    data.City.Name,
-		Conditions:  current.Weather[0].Description,
-		Temperature: current.Main.Temp,
-		FeelsLike:   current.Main.FeelsLike,
-		TempMax:     current.Main.TempMax,
-		TempMin:     current.Main.TempMin,
-		Humidity:    current.Main.Humidity,
-		WindSpeed:   current.Wind.Speed,
+		Location:          data.City.Name,
+		Conditions:        current.Weather[0].Description,
+		WeatherCode:       current.Weather[0].ID,
+		Condition:         conditionFromID(current.Weather[0].ID),
+		PrecipType:        weather.DetectPrecipType(conditionFromID(current.Weather[0].ID), current.Main.Temp),
+		Temperature:       current.Main.Temp,
+		FeelsLike:         current.Main.FeelsLike,
+		TempMax:           current.Main.TempMax,
+		TempMin:           current.Main.TempMin,
+		Humidity:          current.Main.Humidity,
+		WindSpeed:         current.Wind.Speed,
+		WindDirection:     current.Wind.Deg,
+		PrecipProbability: int(math.Round(current.Pop * 100)),
+		Precipitation:     weather.MmToInches(hourly(current.Rain) + hourly(current.Snow)),
+		Pressure:          weather.HpaToInHg(float64(seaLevel(current.Main.SeaLevel, current.Main.Pressure))),
+		ObservedAt:        localTime(current.DateTime, data.City.TimeZone),
+		Sunrise:           localTime(data.City.Sunrise, data.City.TimeZone),
+		Sunset:            localTime(data.City.Sunset, data.City.TimeZone),
+		Latitude:          data.City.Coordinates.Latitude,
+		Longitude:         data.City.Coordinates.Longitude,
+	}
+}
+
+// hourly returns the last hour's volume from v, which may be absent, in mm.
+// Some responses only have the three hour figure, which is averaged.
+func hourly(v *Volume) float64 {
+	switch {
+	case v == nil:
+		return 0
+	case v.OneHour == 0 && v.ThreeHour != 0:
+		return v.ThreeHour / 3
+	default:
+		return v.OneHour
+	}
+}
+
+// seaLevel returns the sea level pressure in hPa, which responses give as
+// sea_level alongside pressure or, in older ones, as pressure alone.
+func seaLevel(seaLevel, pressure int) int {
+	if seaLevel != 0 {
+		return seaLevel
+	}
+	return pressure
+}
+
+// threeHourly returns the three hour volume from v, which may be absent, in
+// mm.
+func threeHourly(v *Volume) float64 {
+	if v == nil {
+		return 0
 	}
+	return v.ThreeHour
+}
+
+// localTime converts a Unix timestamp to the location's time zone, given as
+// an offset from UTC in seconds. OpenWeather omits sunrise and sunset (zero)
+// when the sun doesn't rise or set, so 0 gives the zero time.
+func localTime(unix int64, utcOffsetSeconds int) time.Time {
+	if unix == 0 {
+		return time.Time{}
+	}
+	return time.Unix(unix, 0).In(time.FixedZone("", utcOffsetSeconds))
 }
 
 func (p *Provider) processForecastData(data *ForecastData) []weather.DailyForecast {
@@ -189,14 +674,22 @@ func (p *Provider) processForecastData(data *ForecastData) []weather.DailyForeca
 		high        float64
 		low         float64
 		description string
+		weatherID   int
 		windSpeed   float64
+		windDeg     int
 		humidity    int
+		pop         float64
+		readings    int
 	}
 
 	dailyForecasts := make(map[string]*dailyData)
+	// Precipitation is totaled over the whole day, including the early hours
+	// skipped below.
+	precipByDate := make(map[string]float64)
 
 	for _, item := range data.List {
 		date := strings.Split(item.DateText, " ")[0]
+		precipByDate[date] += threeHourly(item.Rain) + threeHourly(item.Snow)
 		time := strings.Split(item.DateText, " ")[1]
 
 		// Process between 6am and midnight only, trying to get a better feel
@@ -212,24 +705,31 @@ func (p *Provider) processForecastData(data *ForecastData) []weather.DailyForeca
 				high:        -1000,
 				low:         1000,
 				description: item.Weather[0].Description,
+				weatherID:   item.Weather[0].ID,
 				windSpeed:   0,
 				humidity:    item.Main.Humidity,
 			}
 		}
 
 		day := dailyForecasts[date]
+		day.readings++
 		if item.Main.TempMax > day.high {
 			day.high = item.Main.TempMax
 		}
 		if item.Main.TempMin < day.low {
 			day.low = item.Main.TempMin
 		}
+		if item.Pop > day.pop {
+			day.pop = item.Pop
+		}
 		if item.Wind.Speed > day.windSpeed {
 			day.windSpeed = item.Wind.Speed
+			day.windDeg = item.Wind.Deg
 		}
 
 		if strings.Contains(item.DateText, "12:00:00") {
 			day.description = item.Weather[0].Description
+			day.weatherID = item.Weather[0].ID
 			day.humidity = item.Main.Humidity
 		}
 	}
@@ -243,72 +743,314 @@ func (p *Provider) processForecastData(data *ForecastData) []weather.DailyForeca
 	result := make([]weather.DailyForecast, 0, len(dates))
 	for _, date := range dates {
 		day := dailyForecasts[date]
+		if day.readings < p.minReadings {
+			if p.debugMode {
+				fmt.Printf("Debug processForecastData skipping %s: %d readings\n", date, day.readings)
+			}
+			continue
+		}
 		parsedDate, _ := time.Parse("2006-01-02", date)
 		result = append(result, weather.DailyForecast{
-			Date:       parsedDate,
-			Conditions: day.description,
-			High:       day.high,
-			Low:        day.low,
-			WindSpeed:  day.windSpeed,
-			Humidity:   day.humidity,
+			Date:              parsedDate,
+			Conditions:        day.description,
+			WeatherCode:       day.weatherID,
+			Condition:         conditionFromID(day.weatherID),
+			PrecipType:        weather.DetectPrecipType(conditionFromID(day.weatherID), day.high),
+			High:              day.high,
+			Low:               day.low,
+			WindSpeed:         day.windSpeed,
+			WindDirection:     day.windDeg,
+			Humidity:          day.humidity,
+			PrecipProbability: int(math.Round(day.pop * 100)),
+			Precipitation:     weather.MmToInches(precipByDate[date]),
 		})
 	}
 
 	return result
 }
 
-func (p *Provider) fetchData(location string, isForecast bool, target interface{}) error {
+// conditionFromID classifies an OpenWeather condition id, see
+// https://openweathermap.org/weather-conditions
+func conditionFromID(id int) weather.Condition {
+	switch {
+	case id >= 200 && id < 300:
+		return weather.ConditionThunderstorm
+	case id >= 300 && id < 400:
+		return weather.ConditionDrizzle
+	case id == 511:
+		return weather.ConditionFreezingRain
+	case id >= 500 && id < 600:
+		return weather.ConditionRain
+	case id >= 611 && id <= 616:
+		return weather.ConditionSleet
+	case id >= 600 && id < 700:
+		return weather.ConditionSnow
+	case id == 701 || id == 741:
+		return weather.ConditionFog
+	case id == 711 || id == 721 || id == 731 || id == 751 || id == 761 || id == 762:
+		return weather.ConditionHaze
+	case id == 800:
+		return weather.ConditionClear
+	case id == 801 || id == 802:
+		return weather.ConditionPartlyCloudy
+	case id == 803 || id == 804:
+		return weather.ConditionCloudy
+	}
+	return weather.ConditionUnknown
+}
+
+// legacyFixturePrefix is what test files were named with before they were
+// named by provider: weather.weather.json and weather.forecast.json. They're
+// still replayed if there's no openweather.<endpoint>.json.
+const legacyFixturePrefix = "weather"
+
+// fetchData decodes the response from endpoint for location into target. The
+// returned time is when the response was cached, or the zero time if it was
+// fetched live (or replayed from fixtures).
+func (p *Provider) fetchData(ctx context.Context, location, endpoint string, target interface{}) (time.Time, error) {
 	var body []byte
-	var err error
+	var cachedAt time.Time
 
-	if p.useTestData {
-		filename := "weather.weather.json"
-		if isForecast {
-			filename = "weather.forecast.json"
+	if p.fixtures.Replaying() {
+		var err error
+		body, err = p.fixtures.Load("openweather", endpoint)
+		if err != nil {
+			// Test files from before -record were named for the old
+			// provider name, e.g. weather.forecast.json.
+			if legacy, legacyErr := p.fixtures.Load(legacyFixturePrefix, endpoint); legacyErr == nil {
+				body, err = legacy, nil
+			}
 		}
-		body, err = os.ReadFile(filename)
 		if err != nil {
-			return fmt.Errorf("error reading test file: %v", err)
+			return time.Time{}, err
 		}
 	} else {
-		url := p.buildURL(location, isForecast)
-		resp, err := http.Get(url)
+		// The cache is keyed by the redacted URL, so it's shared by all the
+		// keys. Concurrent requests for the same one, as a batch can make,
+		// share one cache lookup and fetch.
+		cacheKey := weather.RedactURL(p.buildURL(location, endpoint, p.keys[0]))
+		v, err, _ := p.flight.Do(cacheKey, func() (interface{}, error) {
+			body, cachedAt, err := p.fetchBody(ctx, location, endpoint, cacheKey)
+			if err == nil && p.fixtures.Recording() {
+				err = p.fixtures.Save("openweather", endpoint, body)
+			}
+			return response{body, cachedAt}, err
+		})
 		if err != nil {
-			return fmt.Errorf("error making request: %v", err)
+			return time.Time{}, err
 		}
-		defer resp.Body.Close()
+		body, cachedAt = v.(response).body, v.(response).cachedAt
+	}
 
-		if p.debugMode {
-			fmt.Printf("Debug fetchData URL: %s\n", url)
-		}
+	if err := json.Unmarshal(body, target); err != nil {
+		return time.Time{}, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	return cachedAt, nil
+}
 
-		body, err = io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("error reading response: %v", err)
+// response is a response body and when it was cached, as for fetchData.
+type response struct {
+	body     []byte
+	cachedAt time.Time
+}
+
+// fetchBody returns the JSON response body for the endpoint and location from
+// the cache, under cacheKey, if it's there, or else from the API, trying each
+// key in turn while rate limited, and caches it.
+func (p *Provider) fetchBody(ctx context.Context, location, endpoint, cacheKey string) ([]byte, time.Time, error) {
+	start := time.Now()
+	if p.cache != nil {
+		if cached, cachedAt, ok := weather.GetCached(p.cache, cacheKey); ok && json.Valid(cached) {
+			if p.debugMode {
+				fmt.Printf("Debug fetchData cache hit from %s\n", cachedAt.Format(time.RFC3339))
+			}
+			p.recordTiming(endpoint, cacheKey, start, true, nil)
+			return cached, cachedAt, nil
 		}
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("API error: %s", string(body))
+	var resp *http.Response
+	var url string
+	var rateLimit *weather.RateLimit
+	var err error
+	for tries := 0; tries < len(p.keys); tries++ {
+		key := p.nextKey()
+		url = p.buildURL(location, endpoint, key)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if err = p.waitForQuota(ctx, key); err != nil {
+			break
 		}
+		// Server errors are retried with any key, but only the last is
+		// worth waiting on while rate limited; the others are given up on
+		// straight away for the next.
+		get := weather.GetRetryingServerErrors
+		if tries == len(p.keys)-1 {
+			get = weather.GetWithRetries
+		}
+		resp, err = get(ctx, url, weather.MaxRetries)
+		rateLimit = nil
+		if err == nil {
+			if rl, ok := weather.ParseRateLimit(resp.Header, time.Now()); ok {
+				p.setRateLimit(key, rl)
+				rateLimit = &rl
+			}
+		}
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			break
+		}
+		if p.debugMode {
+			// On stderr, so it doesn't land in -format=json output.
+			fmt.Fprintf(os.Stderr, "Debug fetchData rate limited, trying the next key\n")
+		}
+	}
+	p.recordTiming(endpoint, url, start, false, rateLimit)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error making request: %v", err)
 	}
+	defer resp.Body.Close()
 
-	if err := json.Unmarshal(body, target); err != nil {
-		return fmt.Errorf("error parsing JSON: %v", err)
+	if p.debugMode {
+		fmt.Printf("Debug fetchData URL: %s\n", p.redact(url))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error reading response: %v", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound && endpoint != "reverse" {
+		return nil, time.Time{}, &weather.LocationNotFoundError{Location: location}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, &apiError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if p.cache != nil && json.Valid(body) {
+		weather.SetCached(p.cache, cacheKey, body, p.cacheTTL)
+	}
+	return body, time.Time{}, nil
+}
+
+// nextKey returns the API key for the next request, taking each in turn.
+func (p *Provider) nextKey() string {
+	n := p.next.Add(1) - 1
+	return p.keys[n%uint32(len(p.keys))]
+}
+
+func (p *Provider) recordTiming(endpoint, url string, start time.Time, cached bool, rl *weather.RateLimit) {
+	if p.timing == nil {
+		return
 	}
+	name := endpoint
+	if endpoint == "reverse" {
+		name = "geocode"
+	}
+	p.timing(weather.RequestTiming{Name: name, URL: p.redact(url), Duration: time.Since(start), Cached: cached, RateLimit: rl})
+}
+
+// setRateLimit records the quota a response for key reported.
+func (p *Provider) setRateLimit(key string, rl weather.RateLimit) {
+	p.limitsMu.Lock()
+	defer p.limitsMu.Unlock()
+	if p.limits == nil {
+		p.limits = make(map[string]weather.RateLimit)
+	}
+	p.limits[key] = rl
+}
 
+// waitForQuota waits, with WithThrottle, as long as key's last reported
+// rate limit says to before the next request with it, or until ctx is done.
+func (p *Provider) waitForQuota(ctx context.Context, key string) error {
+	if !p.throttle {
+		return nil
+	}
+	p.limitsMu.Lock()
+	rl, ok := p.limits[key]
+	p.limitsMu.Unlock()
+	if !ok {
+		return nil
+	}
+	if d := rl.ThrottleDelay(time.Now()); d > 0 {
+		if p.debugMode {
+			fmt.Fprintf(os.Stderr, "Debug fetchData %d calls remaining, waiting %s\n", rl.Remaining, d)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+		}
+	}
 	return nil
 }
 
-func (p *Provider) buildURL(location string, forecast bool) string {
-	endpoint := "weather"
-	if forecast {
-		endpoint = "forecast"
+// RequestURLs returns the URLs GetCurrentWeather, or GetForecast if forecast
+// is set, would request for location, without making any requests.
+func (p *Provider) RequestURLs(location string, forecast bool) []string {
+	var urls []string
+	switch {
+	case !forecast:
+		urls = append(urls, p.buildURL(location, "weather", p.keys[0]))
+	case p.useDaily:
+		// The 5-day forecast is only requested if this is unauthorized.
+		urls = append(urls, p.buildURL(location, "forecast/daily", p.keys[0]), p.buildURL(location, "weather", p.keys[0]))
+	default:
+		urls = append(urls, p.buildURL(location, "forecast", p.keys[0]))
 	}
 
-	if regexp.MustCompile(`^\d{5}$`).MatchString(location) {
-		return fmt.Sprintf("http://api.openweathermap.org/data/2.5/%s?zip=%s,us&units=imperial&appid=%s",
-			endpoint, location, p.apiKey)
+	if _, _, ok := weather.ParseCoordinates(location); ok && p.resolveName {
+		urls = append(urls, p.buildURL(location, "reverse", p.keys[0]))
+	}
+	for i, u := range urls {
+		urls[i] = p.redact(u)
 	}
-	return fmt.Sprintf("http://api.openweathermap.org/data/2.5/%s?q=%s,us&units=imperial&appid=%s",
-		endpoint, url.QueryEscape(location), p.apiKey)
+	return urls
+}
+
+// redact returns u with the API key hidden, unless WithShowKey was given.
+func (p *Provider) redact(u string) string {
+	if p.showKey {
+		return u
+	}
+	return weather.RedactURL(u)
+}
+
+// buildURL returns the URL for endpoint and location, authorized with key.
+func (p *Provider) buildURL(location, endpoint, key string) string {
+	var query string
+	q, _ := weather.ParseLocation(location)
+	switch q.Kind {
+	case weather.LocationCoordinates:
+		query = fmt.Sprintf("lat=%f&lon=%f", q.Lat, q.Lng)
+	case weather.LocationZip:
+		query = fmt.Sprintf("zip=%s,us", q.Zip)
+	case weather.LocationCityState:
+		// OpenWeather's q is "city,state,country", where only US places
+		// take a state. Territories are countries to it, with their
+		// abbreviations as the country code.
+		if weather.Territory(q.State) {
+			query = fmt.Sprintf("q=%s", url.QueryEscape(q.City+","+q.State))
+		} else {
+			query = fmt.Sprintf("q=%s,us", url.QueryEscape(q.City+","+q.State))
+		}
+	case weather.LocationCityCountry:
+		query = fmt.Sprintf("q=%s", url.QueryEscape(q.City+","+q.Country))
+	default:
+		query = fmt.Sprintf("q=%s,us", url.QueryEscape(q.Input))
+	}
+
+	switch endpoint {
+	case "reverse":
+		return fmt.Sprintf("%s/geo/1.0/reverse?%s&limit=1&appid=%s",
+			p.baseURL, query, key)
+	case "forecast/daily":
+		query += fmt.Sprintf("&cnt=%d", dailyForecastDays)
+	}
+	if p.language != "" {
+		query += "&lang=" + url.QueryEscape(p.language)
+	}
+
+	return fmt.Sprintf("%s/data/2.5/%s?%s&units=imperial&appid=%s",
+		p.baseURL, endpoint, query, key)
 }
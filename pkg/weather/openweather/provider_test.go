@@ -0,0 +1,234 @@
+package openweather
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/duluk/weather/pkg/weather"
+)
+
+// fixtureServer serves the recorded responses in testdata as the API would,
+// checking each request is for London in imperial units with the key.
+func fixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	fixture := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			for param, want := range map[string]string{
+				"q":     "London,GB",
+				"units": "imperial",
+				"appid": "test-key",
+			} {
+				if got := q.Get(param); got != want {
+					t.Errorf("%s %s = %q, want %q", r.URL.Path, param, got, want)
+				}
+			}
+			serveFixture(t, w, name)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/data/2.5/weather", fixture("weather.json"))
+	mux.HandleFunc("/data/2.5/forecast", fixture("forecast.json"))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func serveFixture(t *testing.T, w http.ResponseWriter, name string) {
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func TestGetCurrentWeather(t *testing.T) {
+	srv := fixtureServer(t)
+	p := New("test-key", false, WithBaseURL(srv.URL))
+
+	w, err := p.GetCurrentWeather("London, GB")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &weather.CurrentWeather{
+		Location:      "London",
+		Conditions:    "light rain",
+		WeatherCode:   500,
+		Condition:     weather.ConditionRain,
+		PrecipType:    weather.PrecipRain,
+		Temperature:   48.2,
+		FeelsLike:     44.6,
+		TempMax:       50.0,
+		TempMin:       46.4,
+		Humidity:      87,
+		WindSpeed:     11.5,
+		WindDirection: 240,
+		Precipitation: weather.MmToInches(0.51),
+		Pressure:      weather.HpaToInHg(1009),
+		ObservedAt:    time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		Sunrise:       time.Unix(1740811920, 0),
+		Sunset:        time.Unix(1740851340, 0),
+		Latitude:      51.5085,
+		Longitude:     -0.1257,
+	}
+	if !w.Equal(want) {
+		t.Errorf("GetCurrentWeather =\n%+v\nwant\n%+v", w, want)
+	}
+}
+
+func TestGetForecast(t *testing.T) {
+	srv := fixtureServer(t)
+	p := New("test-key", false, WithBaseURL(srv.URL))
+
+	f, err := p.GetForecast("London, GB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Location != "London" {
+		t.Errorf("Location = %q, want London", f.Location)
+	}
+	// The current weather is the first reading.
+	if c := f.Current; c == nil || c.Temperature != 40.1 || c.Conditions != "light rain" || c.PrecipProbability != 60 {
+		t.Errorf("Current = %+v, want the midnight reading: 40.1°F, light rain, 60%%", c)
+	}
+
+	// Each day's high, low, wind and chance of rain are from 6am on, its
+	// conditions from noon and its precipitation the whole day's.
+	want := []weather.DailyForecast{
+		{Date: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC), Conditions: "light rain", WeatherCode: 500,
+			Condition: weather.ConditionRain, PrecipType: weather.PrecipRain,
+			High: 48.9, Low: 39.5, WindSpeed: 16.1, WindDirection: 240, Humidity: 80,
+			PrecipProbability: 90, Precipitation: weather.MmToInches(0.8 + 0.4 + 1.5 + 2.0 + 0.6)},
+		{Date: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), Conditions: "overcast clouds", WeatherCode: 804,
+			Condition: weather.ConditionCloudy,
+			High:      39.2, Low: 33.1, WindSpeed: 11.3, WindDirection: 320, Humidity: 80,
+			PrecipProbability: 50, Precipitation: weather.MmToInches(1.2 + 2.0)},
+	}
+	if len(f.DailyItems) != len(want) {
+		t.Fatalf("got %d days, want %d: %+v", len(f.DailyItems), len(want), f.DailyItems)
+	}
+	for i := range want {
+		got := f.DailyItems[i]
+		// The precipitation is summed in a different order.
+		if diff := got.Precipitation - want[i].Precipitation; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("day %d precipitation = %v, want %v", i+1, got.Precipitation, want[i].Precipitation)
+		}
+		got.Precipitation = want[i].Precipitation
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("day %d =\n%+v\nwant\n%+v", i+1, got, want[i])
+		}
+	}
+}
+
+// A bad request gets a 400 with the message as JSON, which is all the error
+// should say.
+func TestAPIErrorMessage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := os.ReadFile(filepath.Join("testdata", "error400.json"))
+		if err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(body)
+	}))
+	defer srv.Close()
+	p := New("test-key", false, WithBaseURL(srv.URL))
+
+	_, err := p.GetCurrentWeather("London, GB")
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("err = %v, want a 400 apiError", err)
+	}
+	if want := "API error: wrong latitude"; err.Error() != want {
+		t.Errorf("err = %q, want %q", err, want)
+	}
+}
+
+// The caller's deadline reaches the retries, so a 429 asking for a longer
+// wait than is left is returned rather than waited out.
+func TestRateLimitedStopsAtDeadline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	p := New("test-key", false, WithBaseURL(srv.URL))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err := p.GetCurrentWeatherContext(ctx, "London, GB")
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("err = %v, want a 429 apiError", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("took %s, want no wait past the deadline", elapsed)
+	}
+}
+
+// A server error is retried with the same key even when there are others;
+// only a rate limited key is given up on for the next.
+func TestServerErrorRetriedWithEveryKey(t *testing.T) {
+	requests := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("appid")
+		requests[key]++
+		if key == "first-key" && requests[key] == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		serveFixture(t, w, "weather.json")
+	}))
+	defer srv.Close()
+	p := New("first-key", false, WithBaseURL(srv.URL), WithAPIKeys("second-key"))
+
+	if _, err := p.GetCurrentWeather("London, GB"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"first-key": 2}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests by key = %v, want %v", requests, want)
+	}
+}
+
+// Test files named as they were before -record, such as
+// weather.weather.json, are replayed if there's none named for openweather.
+func TestReplayLegacyFixtureNames(t *testing.T) {
+	dir := t.TempDir()
+	body, err := os.ReadFile(filepath.Join("testdata", "weather.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "weather.weather.json"), body, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := New("", false, WithFixtures(&weather.Fixtures{Dir: dir}))
+
+	w, err := p.GetCurrentWeather("London, GB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Location != "London" || w.Temperature != 48.2 {
+		t.Errorf("GetCurrentWeather = %+v, want London at 48.2°F from the legacy file", w)
+	}
+
+	// The new name is preferred.
+	if err := os.WriteFile(filepath.Join(dir, "openweather.weather.json"), []byte(`{"name":"Paris","weather":[{"id":800}],"main":{"temp":60}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if w, err = p.GetCurrentWeather("Paris, FR"); err != nil || w.Location != "Paris" {
+		t.Errorf("GetCurrentWeather = %+v, %v, want Paris from openweather.weather.json", w, err)
+	}
+}
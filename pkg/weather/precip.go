@@ -0,0 +1,69 @@
+package weather
+
+// PrecipType is the kind of precipitation falling, if any, for automation
+// that needs more than the condition text.
+type PrecipType int
+
+const (
+	PrecipNone PrecipType = iota
+	PrecipRain
+	PrecipSnow
+	// PrecipMixed is rain and snow together, such as sleet.
+	PrecipMixed
+	// PrecipFreezing is rain or drizzle that freezes where it lands.
+	PrecipFreezing
+)
+
+var precipTypeNames = map[PrecipType]string{
+	PrecipNone:     "none",
+	PrecipRain:     "rain",
+	PrecipSnow:     "snow",
+	PrecipMixed:    "mixed",
+	PrecipFreezing: "freezing",
+}
+
+func (t PrecipType) String() string {
+	if name, ok := precipTypeNames[t]; ok {
+		return name
+	}
+	return precipTypeNames[PrecipNone]
+}
+
+// MarshalText makes precipitation types appear by name in JSON output.
+func (t PrecipType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// Temperatures (°F) for DetectPrecipType.
+const (
+	// At or below this, rain and drizzle freeze where they land.
+	precipFreezingRain = 32
+	// Above this, snow is likely to be mixed with rain.
+	precipSnowMix = 37
+)
+
+// DetectPrecipType returns the kind of precipitation for condition c at
+// temperature temp (°F): the current temperature, or the high for a day, as
+// rain that falls while even the high is below freezing freezes too.
+// Thunderstorms count as rain.
+func DetectPrecipType(c Condition, temp float64) PrecipType {
+	switch c {
+	case ConditionFreezingRain:
+		return PrecipFreezing
+	case ConditionSleet:
+		return PrecipMixed
+	case ConditionSnow:
+		if temp > precipSnowMix {
+			return PrecipMixed
+		}
+		return PrecipSnow
+	case ConditionDrizzle, ConditionRain:
+		if temp <= precipFreezingRain {
+			return PrecipFreezing
+		}
+		return PrecipRain
+	case ConditionThunderstorm:
+		return PrecipRain
+	}
+	return PrecipNone
+}
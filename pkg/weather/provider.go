@@ -1,34 +1,197 @@
 package weather
 
-import "time"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotSupported is matched, with errors.Is, by the error a provider returns
+// for a request it can't serve at all, such as GetForecast from one that only
+// has current conditions.
+var ErrNotSupported = errors.New("not supported")
 
 type Provider interface {
 	GetCurrentWeather(location string) (*CurrentWeather, error)
+	// GetForecast returns an error matching ErrNotSupported if the provider
+	// has no forecasts.
 	GetForecast(location string) (*Forecast, error)
+	// Attribution is the credit line the data source's terms require when
+	// its data is displayed.
+	Attribution() string
+}
+
+// ContextProvider is implemented by providers whose requests can be
+// cancelled, or given a deadline, with a context, which GetCurrentWeather
+// and GetForecast make with context.Background.
+type ContextProvider interface {
+	GetCurrentWeatherContext(ctx context.Context, location string) (*CurrentWeather, error)
+	GetForecastContext(ctx context.Context, location string) (*Forecast, error)
+}
+
+// CurrentWeatherContext gets the current weather for location from p with
+// ctx. If p isn't a ContextProvider its request can't be stopped, so it's
+// abandoned, still running, when ctx is done.
+func CurrentWeatherContext(ctx context.Context, p Provider, location string) (*CurrentWeather, error) {
+	if cp, ok := p.(ContextProvider); ok {
+		return cp.GetCurrentWeatherContext(ctx, location)
+	}
+	return abandonOnDone(ctx, func() (*CurrentWeather, error) {
+		return p.GetCurrentWeather(location)
+	})
+}
+
+// ForecastContext is CurrentWeatherContext for the forecast.
+func ForecastContext(ctx context.Context, p Provider, location string) (*Forecast, error) {
+	if cp, ok := p.(ContextProvider); ok {
+		return cp.GetForecastContext(ctx, location)
+	}
+	return abandonOnDone(ctx, func() (*Forecast, error) {
+		return p.GetForecast(location)
+	})
+}
+
+// abandonOnDone returns what fetch does, or ctx's error if ctx is done
+// first, leaving fetch to finish on its own.
+func abandonOnDone[T any](ctx context.Context, fetch func() (T, error)) (T, error) {
+	if ctx.Done() == nil {
+		return fetch()
+	}
+
+	type outcome struct {
+		result T
+		err    error
+	}
+	// Buffered so an abandoned fetch can still send and finish.
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := fetch()
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// DryRunner is implemented by providers that can report the API requests they
+// would make for a location without making them.
+type DryRunner interface {
+	RequestURLs(location string, forecast bool) []string
 }
 
 type CurrentWeather struct {
-	Location    string
-	Conditions  string
-	Temperature float64
-	FeelsLike   float64
-	TempMax     float64
-	TempMin     float64
-	Humidity    int
-	WindSpeed   float64
+	Location   string `json:"location"`
+	Conditions string `json:"conditions"`
+	// WeatherCode is the provider's own code for the conditions (a WMO code
+	// for Open-Meteo, a condition id for OpenWeather).
+	WeatherCode int       `json:"weather_code"`
+	Condition   Condition `json:"condition"`
+	// PrecipType is the kind of precipitation falling, from Condition and
+	// Temperature. See DetectPrecipType.
+	PrecipType  PrecipType `json:"precip_type"`
+	Temperature float64    `json:"temperature"`
+	FeelsLike   float64    `json:"feels_like"`
+	TempMax     float64    `json:"temp_max"`
+	TempMin     float64    `json:"temp_min"`
+	Humidity    int        `json:"humidity"`
+	WindSpeed   float64    `json:"wind_speed"`
+	// WindDirection is where the wind comes from in degrees clockwise from
+	// north. It's meaningless when WindSpeed is zero.
+	WindDirection int `json:"wind_direction"`
+	// PrecipProbability is the chance of precipitation today, in percent.
+	// OpenWeather's current conditions don't include it, leaving it zero.
+	PrecipProbability int `json:"precip_probability"`
+	// Precipitation is the rain and snow (as water) in the last hour, in
+	// inches, if the provider reports it.
+	Precipitation float64 `json:"precipitation,omitempty"`
+	// Pressure is the air pressure reduced to sea level, in inches of
+	// mercury, or zero if the provider doesn't report it.
+	Pressure float64 `json:"pressure,omitempty"`
+	// ObservedAt is when the conditions were measured or modeled, in the
+	// location's time zone, or the zero time if the provider doesn't say.
+	ObservedAt time.Time `json:"observed_at"`
+	// Sunrise and Sunset are in the location's time zone, and are the zero
+	// time when unknown or when the sun doesn't rise or set that day.
+	Sunrise time.Time `json:"sunrise"`
+	Sunset  time.Time `json:"sunset"`
+	// TimeZone is the location's IANA time zone, such as "America/Chicago",
+	// if the provider reports it.
+	TimeZone string `json:"timezone,omitempty"`
+	// Elevation is the location's height above sea level in meters, or zero
+	// if the provider doesn't report it.
+	Elevation float64 `json:"elevation,omitempty"`
+	// Latitude and Longitude are the point the weather is for, if the
+	// provider reports it. Gridded models may put it some way from the
+	// location asked for.
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+	// Hourly is the precipitation forecast for the next few hours, from the
+	// current hour, if the provider has one. Its steps are usually hours,
+	// but can be shorter. See NextRain.
+	Hourly []HourlyPrecip `json:"hourly,omitempty"`
+	// Unavailable lists, by JSON name, the measurements the provider gave no
+	// usable value for, which are zero instead. See Sanitize.
+	Unavailable []string `json:"unavailable,omitempty"`
+	// Advisories are notices such as extreme temperature warnings. See
+	// Advise.
+	Advisories []Advisory `json:"advisories,omitempty"`
+	// CachedAt is when the underlying response was fetched if it was served
+	// from cache; it is the zero time for a fresh fetch.
+	CachedAt time.Time `json:"-"`
+}
+
+// HourlyPrecip is the precipitation forecast for the hour, or shorter step,
+// starting at Time.
+type HourlyPrecip struct {
+	Time time.Time `json:"time"`
+	// Precipitation is the step's rain and snow (as water) in inches.
+	Precipitation float64 `json:"precipitation"`
+	// Probability is the chance of precipitation in the hour, in percent.
+	Probability int `json:"probability"`
 }
 
 type DailyForecast struct {
-	Date       time.Time
-	Conditions string
-	High       float64
-	Low        float64
-	WindSpeed  float64
-	Humidity   int
+	Date        time.Time `json:"date"`
+	Conditions  string    `json:"conditions"`
+	WeatherCode int       `json:"weather_code"`
+	Condition   Condition `json:"condition"`
+	// PrecipType is the kind of precipitation expected, from Condition and
+	// High. See DetectPrecipType.
+	PrecipType PrecipType `json:"precip_type"`
+	High       float64    `json:"high"`
+	Low        float64    `json:"low"`
+	WindSpeed  float64    `json:"wind_speed"`
+	// WindDirection is the dominant or strongest wind's direction, as for
+	// CurrentWeather.
+	WindDirection int `json:"wind_direction"`
+	Humidity      int `json:"humidity"`
+	// PrecipProbability is the chance of precipitation during the day, in
+	// percent.
+	PrecipProbability int `json:"precip_probability"`
+	// Precipitation is the day's total rain and snow (as water) in inches,
+	// if the provider reports it.
+	Precipitation float64 `json:"precipitation,omitempty"`
+	// Snowfall is the depth of the day's fresh snow in inches, if the
+	// provider reports it.
+	Snowfall float64 `json:"snowfall,omitempty"`
+	// Unavailable is as for CurrentWeather.
+	Unavailable []string `json:"unavailable,omitempty"`
 }
 
 type Forecast struct {
-	Location   string
-	Current    *CurrentWeather
-	DailyItems []DailyForecast
+	Location string `json:"location"`
+	// Current is the weather now, with today's high and low.
+	Current *CurrentWeather `json:"current,omitempty"`
+	// DailyItems are the days ahead. Whether they start with today, which
+	// Current also describes, depends on the provider; see FromTomorrow.
+	DailyItems []DailyForecast `json:"daily"`
+	// Advisories are notices about the days ahead, such as storms. The
+	// current weather's are in Current.Advisories.
+	Advisories []Advisory `json:"advisories,omitempty"`
+	CachedAt   time.Time  `json:"-"`
 }
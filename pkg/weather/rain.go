@@ -0,0 +1,63 @@
+package weather
+
+import "time"
+
+// DefaultRainyChance is the chance of precipitation (percent) at or above
+// which a day counts as rainy.
+const DefaultRainyChance = 50
+
+// rainy reports whether d counts as rainy: a chance of precipitation of at
+// least chance percent, or precipitating conditions whatever the chance.
+func (d DailyForecast) rainy(chance int) bool {
+	return d.PrecipProbability >= chance || d.Condition.Precipitating()
+}
+
+// RainyDays returns how many days of the forecast have at least chance
+// percent chance of precipitation, or precipitating conditions.
+func (f *Forecast) RainyDays(chance int) int {
+	var n int
+	for _, day := range f.DailyItems {
+		if day.rainy(chance) {
+			n++
+		}
+	}
+	return n
+}
+
+// DryStreak returns the most days in a row of the forecast that aren't rainy
+// as for RainyDays.
+func (f *Forecast) DryStreak(chance int) int {
+	var longest, streak int
+	for _, day := range f.DailyItems {
+		if day.rainy(chance) {
+			streak = 0
+			continue
+		}
+		streak++
+		longest = max(longest, streak)
+	}
+	return longest
+}
+
+// NextRain returns the start of the first step of w.Hourly, from the one now
+// is in until within from now, in which precipitation is expected: some
+// amount forecast, or at least DefaultRainyChance percent chance of it. ok is
+// false if there is none.
+func (w *CurrentWeather) NextRain(now time.Time, within time.Duration) (hour time.Time, ok bool) {
+	step := time.Hour
+	if len(w.Hourly) > 1 {
+		step = w.Hourly[1].Time.Sub(w.Hourly[0].Time)
+	}
+	for _, h := range w.Hourly {
+		if !h.Time.Add(step).After(now) {
+			continue
+		}
+		if h.Time.After(now.Add(within)) {
+			break
+		}
+		if h.Precipitation > 0 || h.Probability >= DefaultRainyChance {
+			return h.Time, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,71 @@
+package weather
+
+import (
+	"testing"
+)
+
+// chances returns a forecast of clear days with the given chances of
+// precipitation.
+func chances(percents ...int) *Forecast {
+	f := &Forecast{}
+	for _, p := range percents {
+		f.DailyItems = append(f.DailyItems, DailyForecast{PrecipProbability: p, Condition: ConditionClear})
+	}
+	return f
+}
+
+func TestRainyDays(t *testing.T) {
+	tests := []struct {
+		name   string
+		f      *Forecast
+		chance int
+		want   int
+	}{
+		// A day exactly at the threshold is rainy; one below isn't.
+		{"at the threshold", chances(50, 49, 51), DefaultRainyChance, 2},
+		{"lower threshold", chances(50, 49, 51, 20), 20, 4},
+		{"none", chances(0, 10, 49), DefaultRainyChance, 0},
+		{"no days", chances(), DefaultRainyChance, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.f.RainyDays(tt.chance); got != tt.want {
+			t.Errorf("%s: RainyDays(%d) = %d, want %d", tt.name, tt.chance, got, tt.want)
+		}
+	}
+}
+
+// Precipitating conditions make a day rainy whatever its chance.
+func TestRainyDaysConditions(t *testing.T) {
+	f := chances(0, 0, 0)
+	f.DailyItems[1].Condition = ConditionSnow
+	if got := f.RainyDays(DefaultRainyChance); got != 1 {
+		t.Errorf("RainyDays = %d, want the snowy day", got)
+	}
+	if got := f.DryStreak(DefaultRainyChance); got != 1 {
+		t.Errorf("DryStreak = %d, want 1 either side of the snowy day", got)
+	}
+}
+
+func TestDryStreak(t *testing.T) {
+	tests := []struct {
+		name   string
+		f      *Forecast
+		chance int
+		want   int
+	}{
+		{"in the middle", chances(80, 10, 10, 10, 60, 10), DefaultRainyChance, 3},
+		// The longest streak runs to the end of the forecast.
+		{"at the end", chances(10, 70, 10, 20, 30, 40), DefaultRainyChance, 4},
+		{"at the start", chances(10, 20, 90, 10), DefaultRainyChance, 2},
+		{"all dry", chances(0, 10, 49), DefaultRainyChance, 3},
+		// 50% is rainy, breaking the streak; 49% isn't.
+		{"threshold breaks it", chances(49, 50, 49), DefaultRainyChance, 1},
+		{"all rainy", chances(50, 90), DefaultRainyChance, 0},
+		{"no days", chances(), DefaultRainyChance, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.f.DryStreak(tt.chance); got != tt.want {
+			t.Errorf("%s: DryStreak(%d) = %d, want %d", tt.name, tt.chance, got, tt.want)
+		}
+	}
+}
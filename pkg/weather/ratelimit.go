@@ -0,0 +1,62 @@
+package weather
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit is an API's quota as of a response, from its X-RateLimit
+// headers, which some plans send.
+type RateLimit struct {
+	// Limit is the number of calls allowed in the current window, or 0 if
+	// the response didn't say.
+	Limit int
+	// Remaining is the number of calls left in the window.
+	Remaining int
+	// Reset is when the window ends, or the zero time if the response
+	// didn't say.
+	Reset time.Time
+}
+
+// unixResetAfter is the X-RateLimit-Reset value above which it's taken to be
+// a Unix time rather than seconds from now; a window isn't a decade long.
+const unixResetAfter = 10 * 365 * 24 * 60 * 60
+
+// ParseRateLimit reads the X-RateLimit-Limit, -Remaining and -Reset headers
+// of h, as of now. The reset is in seconds, either from now or since the
+// Unix epoch. ok is false unless there's a remaining count.
+func ParseRateLimit(h http.Header, now time.Time) (rl RateLimit, ok bool) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil || remaining < 0 {
+		return RateLimit{}, false
+	}
+	rl.Remaining = remaining
+	if limit, err := strconv.Atoi(h.Get("X-RateLimit-Limit")); err == nil && limit > 0 {
+		rl.Limit = limit
+	}
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil && reset >= 0 {
+		if reset > unixResetAfter {
+			rl.Reset = time.Unix(reset, 0)
+		} else {
+			rl.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+	return rl, true
+}
+
+// throttleShare is the fraction of the limit below which ThrottleDelay
+// starts spacing calls out.
+const throttleShare = 10
+
+// ThrottleDelay returns how long to wait before the next call to spread the
+// remaining calls over what's left of the window, once fewer than a tenth of
+// the limit (or, without a limit, a handful) remain. It's never more than
+// MaxRetryDelay, and zero if there's no need to wait or no reset time.
+func (rl RateLimit) ThrottleDelay(now time.Time) time.Duration {
+	low := max(rl.Limit/throttleShare, 5)
+	if rl.Remaining >= low || rl.Reset.IsZero() || !rl.Reset.After(now) {
+		return 0
+	}
+	return min(rl.Reset.Sub(now)/time.Duration(rl.Remaining+1), MaxRetryDelay)
+}
@@ -0,0 +1,27 @@
+package weather
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+)
+
+// secretParamRE matches the value of a URL query parameter that holds an API
+// key or token.
+var secretParamRE = regexp.MustCompile(`(?i)([?&](?:appid|api_?key|key|token)=)[^&#]*`)
+
+// RedactURL returns rawURL with any API key or token in its query replaced by
+// "***", so it can be shown or logged.
+func RedactURL(rawURL string) string {
+	return secretParamRE.ReplaceAllString(rawURL, "${1}***")
+}
+
+// redactError redacts the URL in err if it's a *url.Error, as those from
+// http.Client.Do are, since their messages include it.
+func redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = RedactURL(urlErr.URL)
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package weather
+
+// Resolution explains how a provider turned a location into the place it
+// fetches the weather for.
+type Resolution struct {
+	// Input is the location as given.
+	Input string
+	// Form is what the location was parsed as: "coordinates", "zip",
+	// "city, state" or "name".
+	Form string
+	// Name and State are the parts of the location searched for. State is
+	// empty unless Form is "city, state".
+	Name  string
+	State string
+	// Query is the geocoding request made, if any.
+	Query string
+	// Candidates is how many places the query returned.
+	Candidates int
+	// Chosen is the place used.
+	Chosen Place
+	// Reason says why Chosen was picked.
+	Reason string
+}
+
+// Place is a geocoded location.
+type Place struct {
+	Name       string
+	State      string
+	Country    string
+	Latitude   float64
+	Longitude  float64
+	Population int
+}
+
+// LocationExplainer is implemented by providers that can explain how they
+// resolve a location, for troubleshooting a surprising result.
+type LocationExplainer interface {
+	ExplainLocation(location string) (*Resolution, error)
+}
@@ -0,0 +1,167 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// MaxRetries is how many times GetWithRetry retries a rate limited or failed
+// request, and MaxRetryDelay the longest it waits before any one retry, even
+// if the server asks for longer.
+const (
+	MaxRetries    = 3
+	MaxRetryDelay = 30 * time.Second
+)
+
+// retryBaseDelay is the wait before the first retry, doubled for each one
+// after, when the server doesn't say how long to wait.
+const retryBaseDelay = 500 * time.Millisecond
+
+// maxRedirects is how many redirects a request follows before giving up.
+const maxRedirects = 5
+
+// errInsecureRedirect is returned for a redirect from https to http, which
+// would send the request, API key and all, in the clear.
+var errInsecureRedirect = errors.New("refusing redirect from https to http")
+
+// checkRedirect follows redirects, but only so many, and never from https to
+// http.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if via[len(via)-1].URL.Scheme == "https" && req.URL.Scheme != "https" {
+		return errInsecureRedirect
+	}
+	return nil
+}
+
+// TransportSettings tune the pool of connections every provider's requests
+// share, which a server answering many requests may want larger.
+type TransportSettings struct {
+	// MaxIdleConns is the most idle connections kept open in all, and
+	// MaxIdleConnsPerHost to any one API host; 0 is no limit.
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept open; 0 is
+	// forever.
+	IdleConnTimeout time.Duration
+	// DisableKeepAlives makes a new connection for every request.
+	DisableKeepAlives bool
+}
+
+// DefaultTransportSettings keep more connections to each host open than
+// net/http's default of two, as requests go to only a few API hosts.
+var DefaultTransportSettings = TransportSettings{
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 16,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// newHTTPClient returns a client whose transport is net/http's default, with
+// its proxy and TLS settings, tuned by s.
+func newHTTPClient(s TransportSettings) *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = s.MaxIdleConns
+	t.MaxIdleConnsPerHost = s.MaxIdleConnsPerHost
+	t.IdleConnTimeout = s.IdleConnTimeout
+	t.DisableKeepAlives = s.DisableKeepAlives
+	return &http.Client{Transport: t, CheckRedirect: checkRedirect}
+}
+
+// httpClient is the one client all requests are made with, so connections
+// are reused across requests and providers.
+var httpClient atomic.Pointer[http.Client]
+
+func init() {
+	httpClient.Store(newHTTPClient(DefaultTransportSettings))
+}
+
+// SetTransportSettings replaces the shared connection pool with one tuned by
+// s, closing the old one's idle connections. Requests already under way
+// finish on the old one.
+func SetTransportSettings(s TransportSettings) {
+	old := httpClient.Swap(newHTTPClient(s))
+	old.CloseIdleConnections()
+}
+
+// GetWithRetry GETs url, retrying responses that are rate limited (429) or
+// server errors (5xx) with exponential backoff. A Retry-After header, in
+// seconds or as an HTTP date, is waited out instead of the backoff. If a wait
+// would pass ctx's deadline, the last response is returned as is. As with
+// http.Get, the caller must close the response body. Any API key in url is
+// redacted from the errors returned.
+func GetWithRetry(ctx context.Context, url string) (*http.Response, error) {
+	return GetWithRetries(ctx, url, MaxRetries)
+}
+
+// GetWithRetries is GetWithRetry with at most retries retries, for callers
+// with a better way to recover, such as another API key to try.
+func GetWithRetries(ctx context.Context, url string, retries int) (*http.Response, error) {
+	return getWithRetries(ctx, url, retries, retryableStatus)
+}
+
+// GetRetryingServerErrors is GetWithRetries, but only server errors are
+// retried: a rate limited response is returned straight away, for callers
+// with another API key to try instead.
+func GetRetryingServerErrors(ctx context.Context, url string, retries int) (*http.Response, error) {
+	return getWithRetries(ctx, url, retries, func(code int) bool { return code >= 500 })
+}
+
+// getWithRetries GETs url, retrying the statuses retryable reports up to
+// retries times.
+func getWithRetries(ctx context.Context, url string, retries int, retryable func(int) bool) (*http.Response, error) {
+	delay := retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, redactError(err)
+		}
+		resp, err := httpClient.Load().Do(req)
+		if err != nil || attempt >= retries || !retryable(resp.StatusCode) {
+			return resp, redactError(err)
+		}
+
+		wait := delay
+		if d, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			wait = d
+		}
+		wait = min(wait, MaxRetryDelay)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// retryAfter parses a Retry-After header value, either a number of seconds or
+// an HTTP date, into how long to wait from now. ok is false if value is empty
+// or malformed.
+func retryAfter(value string, now time.Time) (d time.Duration, ok bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
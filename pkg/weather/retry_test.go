@@ -0,0 +1,127 @@
+package weather
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// rateLimitedServer answers every request with a 429 asking for retryAfter
+// to be waited, counting the requests.
+func rateLimitedServer(t *testing.T, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", retryAfter)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"120", 2 * time.Minute, true},
+		{"-5", 0, true},
+		{"Sat, 01 Mar 2025 12:00:30 GMT", 30 * time.Second, true},
+		{"Sat, 01 Mar 2025 11:59:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.value, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestGetWithRetriesHonorsRetryAfter(t *testing.T) {
+	srv, requests := rateLimitedServer(t, "0")
+
+	resp, err := GetWithRetries(context.Background(), srv.URL, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want the last 429", resp.StatusCode)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("made %d requests, want the first and 2 retries", n)
+	}
+}
+
+// A rate limited response is returned straight away, for the caller to try
+// another key.
+func TestGetRetryingServerErrorsReturnsRateLimited(t *testing.T) {
+	srv, requests := rateLimitedServer(t, "0")
+
+	resp, err := GetRetryingServerErrors(context.Background(), srv.URL, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if n := requests.Load(); resp.StatusCode != http.StatusTooManyRequests || n != 1 {
+		t.Errorf("status = %d after %d requests, want the first 429", resp.StatusCode, n)
+	}
+}
+
+// A wait that would pass the deadline isn't started: the rate limited
+// response is returned straight away, rather than the caller's time being
+// spent waiting for a retry it can't make.
+func TestGetWithRetriesStopsAtDeadline(t *testing.T) {
+	srv, requests := rateLimitedServer(t, "10")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	resp, err := GetWithRetry(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("took %s, want no wait past the deadline", elapsed)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want the 429", resp.StatusCode)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("made %d requests, want 1", n)
+	}
+}
+
+// Without a Retry-After, the backoff is waited out until the deadline would
+// be passed.
+func TestGetWithRetriesBackoffStopsAtDeadline(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	// Enough for the first wait of retryBaseDelay, but not the doubled
+	// second.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*retryBaseDelay)
+	defer cancel()
+	resp, err := GetWithRetry(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if n := requests.Load(); n != 2 {
+		t.Errorf("made %d requests, want the first and one retry", n)
+	}
+}
@@ -0,0 +1,42 @@
+package weather
+
+import (
+	"math"
+	"slices"
+)
+
+// Rounded returns a copy of w with its temperatures rounded to the nearest
+// degree Fahrenheit, for consumers that want whole numbers. w keeps the full
+// precision.
+func (w *CurrentWeather) Rounded() *CurrentWeather {
+	r := *w
+	r.Unavailable = slices.Clone(w.Unavailable)
+	r.Temperature = math.Round(w.Temperature)
+	r.FeelsLike = math.Round(w.FeelsLike)
+	r.TempMax = math.Round(w.TempMax)
+	r.TempMin = math.Round(w.TempMin)
+	return &r
+}
+
+// Rounded is CurrentWeather.Rounded for a day of a forecast.
+func (d *DailyForecast) Rounded() *DailyForecast {
+	r := *d
+	r.Unavailable = slices.Clone(d.Unavailable)
+	r.High = math.Round(d.High)
+	r.Low = math.Round(d.Low)
+	return &r
+}
+
+// Rounded returns a copy of f with the temperatures of the current weather,
+// if any, and every day rounded as by CurrentWeather.Rounded.
+func (f *Forecast) Rounded() *Forecast {
+	r := *f
+	if f.Current != nil {
+		r.Current = f.Current.Rounded()
+	}
+	r.DailyItems = make([]DailyForecast, len(f.DailyItems))
+	for i := range f.DailyItems {
+		r.DailyItems[i] = *f.DailyItems[i].Rounded()
+	}
+	return &r
+}
@@ -0,0 +1,59 @@
+package weather
+
+import (
+	"math"
+	"slices"
+)
+
+// sanitize zeroes *v if it's NaN or infinite, adding name to unavailable. It
+// leaves finite values alone, so sanitizing twice changes nothing.
+func sanitize(v *float64, name string, unavailable *[]string) {
+	if math.IsNaN(*v) || math.IsInf(*v, 0) {
+		*v = 0
+		*unavailable = append(*unavailable, name)
+	}
+}
+
+// Sanitize zeroes any measurement in w that isn't a finite number, listing it
+// in Unavailable so it isn't shown as a real zero. Providers call it on what
+// they return.
+func (w *CurrentWeather) Sanitize() {
+	sanitize(&w.Temperature, "temperature", &w.Unavailable)
+	sanitize(&w.FeelsLike, "feels_like", &w.Unavailable)
+	sanitize(&w.TempMax, "temp_max", &w.Unavailable)
+	sanitize(&w.TempMin, "temp_min", &w.Unavailable)
+	sanitize(&w.WindSpeed, "wind_speed", &w.Unavailable)
+	sanitize(&w.Precipitation, "precipitation", &w.Unavailable)
+	sanitize(&w.Pressure, "pressure", &w.Unavailable)
+	sanitize(&w.Elevation, "elevation", &w.Unavailable)
+}
+
+// Available reports whether the measurement with the given JSON name, such
+// as "temperature", is real rather than zeroed by Sanitize.
+func (w *CurrentWeather) Available(name string) bool {
+	return !slices.Contains(w.Unavailable, name)
+}
+
+// Sanitize is CurrentWeather.Sanitize for a day of a forecast.
+func (d *DailyForecast) Sanitize() {
+	sanitize(&d.High, "high", &d.Unavailable)
+	sanitize(&d.Low, "low", &d.Unavailable)
+	sanitize(&d.WindSpeed, "wind_speed", &d.Unavailable)
+	sanitize(&d.Precipitation, "precipitation", &d.Unavailable)
+	sanitize(&d.Snowfall, "snowfall", &d.Unavailable)
+}
+
+// Available is CurrentWeather.Available for a day of a forecast.
+func (d *DailyForecast) Available(name string) bool {
+	return !slices.Contains(d.Unavailable, name)
+}
+
+// Sanitize sanitizes the current weather, if any, and every day of f.
+func (f *Forecast) Sanitize() {
+	if f.Current != nil {
+		f.Current.Sanitize()
+	}
+	for i := range f.DailyItems {
+		f.DailyItems[i].Sanitize()
+	}
+}
@@ -0,0 +1,69 @@
+package weather
+
+import (
+	"sort"
+	"strings"
+)
+
+// ConditionSeverities says how severe each condition is, for advisories and
+// for coloring conditions. Conditions it doesn't list are SeverityNone.
+type ConditionSeverities map[Condition]Severity
+
+// DefaultConditionSeverities rate conditions that are dangerous to be out in
+// as high, and ones that spoil plans as medium or low.
+var DefaultConditionSeverities = ConditionSeverities{
+	ConditionThunderstorm: SeverityHigh,
+	ConditionFreezingRain: SeverityHigh,
+	ConditionSnow:         SeverityMedium,
+	ConditionSleet:        SeverityMedium,
+	ConditionRain:         SeverityMedium,
+	ConditionDrizzle:      SeverityLow,
+	ConditionFog:          SeverityLow,
+	ConditionHaze:         SeverityLow,
+}
+
+// Severity returns how severe c is.
+func (s ConditionSeverities) Severity(c Condition) Severity {
+	return s[c]
+}
+
+// conditionPhrases name conditions as they're forecast in advisories, where
+// the plain name reads oddly.
+var conditionPhrases = map[Condition]string{
+	ConditionThunderstorm: "Thunderstorms",
+	ConditionClear:        "Clear skies",
+	ConditionPartlyCloudy: "Partly cloudy skies",
+	ConditionCloudy:       "Cloudy skies",
+}
+
+// ConditionAdvisories warns of the days in f with high severity conditions
+// forecast, with one advisory for each such condition, most severe first.
+func ConditionAdvisories(f *Forecast, s ConditionSeverities) []Advisory {
+	days := make(map[Condition][]string)
+	var conditions []Condition
+	for _, day := range f.DailyItems {
+		if s.Severity(day.Condition) < SeverityHigh {
+			continue
+		}
+		if _, seen := days[day.Condition]; !seen {
+			conditions = append(conditions, day.Condition)
+		}
+		days[day.Condition] = append(days[day.Condition], day.Date.Format("Monday"))
+	}
+	sort.SliceStable(conditions, func(i, j int) bool {
+		return s.Severity(conditions[i]) > s.Severity(conditions[j])
+	})
+
+	var advisories []Advisory
+	for _, c := range conditions {
+		phrase, ok := conditionPhrases[c]
+		if !ok {
+			phrase = strings.ToUpper(c.String()[:1]) + c.String()[1:]
+		}
+		advisories = append(advisories, Advisory{
+			Severity: s.Severity(c),
+			Message:  phrase + " likely " + strings.Join(days[c], ", "),
+		})
+	}
+	return advisories
+}
@@ -0,0 +1,105 @@
+package weather
+
+import "strings"
+
+// stateAbbreviations maps the US state names, as geocoders report them, to
+// their postal abbreviations. DC and the territories have them too, so
+// "Washington, DC" is a city and state rather than a city and country.
+var stateAbbreviations = map[string]string{
+	"Alabama":        "AL",
+	"Alaska":         "AK",
+	"Arizona":        "AZ",
+	"Arkansas":       "AR",
+	"California":     "CA",
+	"Colorado":       "CO",
+	"Connecticut":    "CT",
+	"Delaware":       "DE",
+	"Florida":        "FL",
+	"Georgia":        "GA",
+	"Hawaii":         "HI",
+	"Idaho":          "ID",
+	"Illinois":       "IL",
+	"Indiana":        "IN",
+	"Iowa":           "IA",
+	"Kansas":         "KS",
+	"Kentucky":       "KY",
+	"Louisiana":      "LA",
+	"Maine":          "ME",
+	"Maryland":       "MD",
+	"Massachusetts":  "MA",
+	"Michigan":       "MI",
+	"Minnesota":      "MN",
+	"Mississippi":    "MS",
+	"Missouri":       "MO",
+	"Montana":        "MT",
+	"Nebraska":       "NE",
+	"Nevada":         "NV",
+	"New Hampshire":  "NH",
+	"New Jersey":     "NJ",
+	"New Mexico":     "NM",
+	"New York":       "NY",
+	"North Carolina": "NC",
+	"North Dakota":   "ND",
+	"Ohio":           "OH",
+	"Oklahoma":       "OK",
+	"Oregon":         "OR",
+	"Pennsylvania":   "PA",
+	"Rhode Island":   "RI",
+	"South Carolina": "SC",
+	"South Dakota":   "SD",
+	"Tennessee":      "TN",
+	"Texas":          "TX",
+	"Utah":           "UT",
+	"Vermont":        "VT",
+	"Virginia":       "VA",
+	"Washington":     "WA",
+	"West Virginia":  "WV",
+	"Wisconsin":      "WI",
+	"Wyoming":        "WY",
+
+	"District of Columbia":     "DC",
+	"American Samoa":           "AS",
+	"Guam":                     "GU",
+	"Northern Mariana Islands": "MP",
+	"Puerto Rico":              "PR",
+	"U.S. Virgin Islands":      "VI",
+}
+
+// territories are the abbreviations of the US territories, which geocoders
+// list as countries of their own, with the abbreviation as the country code.
+var territories = map[string]bool{
+	"AS": true,
+	"GU": true,
+	"MP": true,
+	"PR": true,
+	"VI": true,
+}
+
+// StateAbbreviation returns the postal abbreviation of the US state named
+// name, such as "OR" for "Oregon", ignoring case and surrounding space.
+func StateAbbreviation(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	for full, abbr := range stateAbbreviations {
+		if strings.EqualFold(full, name) {
+			return abbr, true
+		}
+	}
+	return "", false
+}
+
+// StateName returns the full name of the US state abbreviated abbr, as
+// geocoders report it, or abbr if it isn't known.
+func StateName(abbr string) string {
+	for name, a := range stateAbbreviations {
+		if strings.EqualFold(a, strings.TrimSpace(abbr)) {
+			return name
+		}
+	}
+	return abbr
+}
+
+// Territory reports whether abbr is the abbreviation of a US territory, such
+// as "PR", rather than of a state or DC.
+func Territory(abbr string) bool {
+	return territories[strings.ToUpper(strings.TrimSpace(abbr))]
+}
@@ -0,0 +1,14 @@
+package weather
+
+import "time"
+
+// DaylightRemaining returns the daylight left between now and sunset. It is
+// never negative: it's zero after sunset and when sunset is unknown (the zero
+// time), which providers report when the sun doesn't rise or set that day.
+// Callers should check that now is after sunrise if that matters to them.
+func DaylightRemaining(now, sunset time.Time) time.Duration {
+	if sunset.IsZero() || !now.Before(sunset) {
+		return 0
+	}
+	return sunset.Sub(now)
+}
@@ -0,0 +1,22 @@
+package weather
+
+import "time"
+
+// RequestTiming is how long a provider took over one API request.
+type RequestTiming struct {
+	// Name is the kind of request, such as "geocode" or "forecast".
+	Name     string
+	URL      string
+	Duration time.Duration
+	// Cached is set when the response came from the cache rather than the
+	// API.
+	Cached bool
+	// RateLimit is the API's quota after the request, if the response
+	// reported it.
+	RateLimit *RateLimit
+}
+
+// TimingFunc receives the timing of each request a provider makes, when set
+// with the provider's WithTiming option. Batch fetches call it from several
+// goroutines at once.
+type TimingFunc func(RequestTiming)
@@ -0,0 +1,52 @@
+package weather
+
+type Trend int
+
+const (
+	Steady Trend = iota
+	Warming
+	Cooling
+)
+
+func (t Trend) String() string {
+	switch t {
+	case Warming:
+		return "warming"
+	case Cooling:
+		return "cooling"
+	default:
+		return "steady"
+	}
+}
+
+// steadyTrendSlope is the change in daily high (°F per day) below which the
+// trend is considered steady.
+const steadyTrendSlope = 1.0
+
+// TempTrend classifies the direction of the daily highs using the slope of a
+// least-squares line through them. Fewer than two days is Steady.
+func (f *Forecast) TempTrend() Trend {
+	n := float64(len(f.DailyItems))
+	if n < 2 {
+		return Steady
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, day := range f.DailyItems {
+		x := float64(i)
+		sumX += x
+		sumY += day.High
+		sumXY += x * day.High
+		sumXX += x * x
+	}
+	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+
+	switch {
+	case slope >= steadyTrendSlope:
+		return Warming
+	case slope <= -steadyTrendSlope:
+		return Cooling
+	default:
+		return Steady
+	}
+}
@@ -0,0 +1,47 @@
+package weather
+
+import (
+	"testing"
+)
+
+// highs returns a forecast whose days have the given highs.
+func highs(temps ...float64) *Forecast {
+	f := &Forecast{}
+	for _, t := range temps {
+		f.DailyItems = append(f.DailyItems, DailyForecast{High: t})
+	}
+	return f
+}
+
+func TestTempTrend(t *testing.T) {
+	tests := []struct {
+		name string
+		f    *Forecast
+		want Trend
+	}{
+		{"rising", highs(50, 53, 55, 58, 62), Warming},
+		{"falling", highs(70, 66, 65, 61, 58), Cooling},
+		{"steady", highs(60, 61, 59, 60, 61), Steady},
+		// A single hot or cold day doesn't make a trend.
+		{"spike", highs(60, 60, 75, 60, 60), Steady},
+		// Exactly the threshold of a degree a day is a trend.
+		{"rising at the threshold", highs(60, 61, 62), Warming},
+		{"falling at the threshold", highs(60, 59, 58), Cooling},
+		{"two days rising", highs(50, 55), Warming},
+		{"one day", highs(80), Steady},
+		{"no days", highs(), Steady},
+	}
+	for _, tt := range tests {
+		if got := tt.f.TempTrend(); got != tt.want {
+			t.Errorf("%s: TempTrend = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTrendString(t *testing.T) {
+	for trend, want := range map[Trend]string{Warming: "warming", Cooling: "cooling", Steady: "steady"} {
+		if got := trend.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", trend, got, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package weather
+
+// Unit conversions for the values in CurrentWeather and DailyForecast, which
+// providers report in imperial units (°F, mph, inches).
+
+func FtoC(f float64) float64 {
+	return (f - 32) * 5 / 9
+}
+
+func CtoF(c float64) float64 {
+	return c*9/5 + 32
+}
+
+func CtoK(c float64) float64 {
+	return c + 273.15
+}
+
+func KtoC(k float64) float64 {
+	return k - 273.15
+}
+
+func MphToKph(mph float64) float64 {
+	return mph * 1.609344
+}
+
+func KphToMph(kph float64) float64 {
+	return kph / 1.609344
+}
+
+func MphToKnots(mph float64) float64 {
+	return mph * 0.868976
+}
+
+func KnotsToMph(knots float64) float64 {
+	return knots / 0.868976
+}
+
+func MphToMps(mph float64) float64 {
+	return mph * 0.44704
+}
+
+func MpsToMph(mps float64) float64 {
+	return mps / 0.44704
+}
+
+// HpaToInHg converts hectopascals (millibars) to inches of mercury.
+func HpaToInHg(hpa float64) float64 {
+	return hpa * 0.0295300
+}
+
+func InHgToHpa(inHg float64) float64 {
+	return inHg / 0.0295300
+}
+
+func MmToInches(mm float64) float64 {
+	return mm / 25.4
+}
+
+func InchesToMm(in float64) float64 {
+	return in * 25.4
+}
+
+func InchesToCm(in float64) float64 {
+	return in * 2.54
+}
+
+func KmToMiles(km float64) float64 {
+	return km / 1.609344
+}
@@ -0,0 +1,69 @@
+package weather
+
+import (
+	"testing"
+)
+
+func TestConverters(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(float64) float64
+		in   float64
+		want float64
+	}{
+		{"FtoC freezing", FtoC, 32, 0},
+		{"FtoC boiling", FtoC, 212, 100},
+		{"FtoC -40", FtoC, -40, -40},
+		{"CtoF freezing", CtoF, 0, 32},
+		{"CtoF body", CtoF, 37, 98.6},
+		{"CtoK freezing", CtoK, 0, 273.15},
+		{"KtoC absolute zero", KtoC, 0, -273.15},
+		{"MphToKph", MphToKph, 60, 96.56064},
+		{"KphToMph", KphToMph, 100, 62.137119},
+		{"MphToKnots", MphToKnots, 100, 86.8976},
+		{"KnotsToMph", KnotsToMph, 10, 11.507794},
+		{"MphToMps", MphToMps, 10, 4.4704},
+		{"MpsToMph", MpsToMph, 1, 2.236936},
+		{"HpaToInHg standard", HpaToInHg, 1013.25, 29.921},
+		{"InHgToHpa standard", InHgToHpa, 29.92, 1013.207},
+		{"MmToInches", MmToInches, 25.4, 1},
+		{"InchesToMm", InchesToMm, 2, 50.8},
+		{"InchesToCm", InchesToCm, 10, 25.4},
+		{"KmToMiles", KmToMiles, 1.609344, 1},
+		{"KmToMiles marathon", KmToMiles, 42.195, 26.219},
+	}
+	for _, tt := range tests {
+		// The expected values are rounded, to three places at most.
+		if got := tt.fn(tt.in); !within(got, tt.want, 0.001) {
+			t.Errorf("%s(%v) = %v, want %v", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+// Converting there and back gives what was converted.
+func TestConverterRoundTrips(t *testing.T) {
+	pairs := []struct {
+		name     string
+		to, back func(float64) float64
+	}{
+		{"FtoC/CtoF", FtoC, CtoF},
+		{"CtoK/KtoC", CtoK, KtoC},
+		{"MphToKph/KphToMph", MphToKph, KphToMph},
+		{"MphToKnots/KnotsToMph", MphToKnots, KnotsToMph},
+		{"MphToMps/MpsToMph", MphToMps, MpsToMph},
+		{"HpaToInHg/InHgToHpa", HpaToInHg, InHgToHpa},
+		{"InchesToMm/MmToInches", InchesToMm, MmToInches},
+	}
+	for _, p := range pairs {
+		for _, v := range []float64{-40, 0, 0.01, 12.5, 98.6, 1013.25} {
+			if got := p.back(p.to(v)); !within(got, v, 1e-9) {
+				t.Errorf("%s(%v) = %v", p.name, v, got)
+			}
+		}
+	}
+}
+
+func within(a, b, tolerance float64) bool {
+	d := a - b
+	return d <= tolerance && d >= -tolerance
+}
@@ -0,0 +1,51 @@
+package weather
+
+import "time"
+
+// WeekSummary is the days of a forecast in one calendar week, Monday to
+// Sunday, with their averages.
+type WeekSummary struct {
+	// Start is the Monday the week starts on, which may be before the
+	// forecast does.
+	Start     time.Time
+	Days      []DailyForecast
+	AvgHigh   float64
+	AvgLow    float64
+	RainyDays int
+}
+
+// Weeks groups the forecast's days by calendar week, in order. Days count as
+// rainy as for DefaultRainyChance.
+func (f *Forecast) Weeks() []WeekSummary {
+	var weeks []WeekSummary
+	for _, day := range f.DailyItems {
+		start := weekStart(day.Date)
+		if len(weeks) == 0 || !weeks[len(weeks)-1].Start.Equal(start) {
+			weeks = append(weeks, WeekSummary{Start: start})
+		}
+		w := &weeks[len(weeks)-1]
+		w.Days = append(w.Days, day)
+		if day.rainy(DefaultRainyChance) {
+			w.RainyDays++
+		}
+	}
+
+	for i := range weeks {
+		w := &weeks[i]
+		for _, day := range w.Days {
+			w.AvgHigh += day.High
+			w.AvgLow += day.Low
+		}
+		w.AvgHigh /= float64(len(w.Days))
+		w.AvgLow /= float64(len(w.Days))
+	}
+	return weeks
+}
+
+// weekStart returns the Monday of date's week.
+func weekStart(date time.Time) time.Time {
+	// Weekday counts from Sunday = 0; shift it so Monday is 0.
+	offset := (int(date.Weekday()) + 6) % 7
+	y, m, d := date.AddDate(0, 0, -offset).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, date.Location())
+}
@@ -0,0 +1,114 @@
+package weather
+
+import (
+	"fmt"
+	"math"
+)
+
+var compassPoints = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+
+// Arrows point the way the wind is blowing, which is opposite to the
+// direction it comes from: a north wind blows south, ↓.
+var windArrows = []string{"↓", "↙", "←", "↖", "↑", "↗", "→", "↘"}
+
+// compassIndex returns which of the eight compass points degrees is nearest.
+func compassIndex(degrees float64) int {
+	d := math.Mod(degrees, 360)
+	if d < 0 {
+		d += 360
+	}
+	return int(math.Round(d/45)) % 8
+}
+
+// CompassDirection returns the eight-point compass direction (e.g. "NW") for
+// a wind direction in degrees, which is where the wind comes from.
+func CompassDirection(degrees float64) string {
+	return compassPoints[compassIndex(degrees)]
+}
+
+// WindArrow returns an arrow showing the way a wind from degrees is blowing.
+func WindArrow(degrees float64) string {
+	return windArrows[compassIndex(degrees)]
+}
+
+// WindSummary describes how the wind direction changes over the forecast in
+// a short phrase, such as "winds shifting from SW to NW by Wednesday" or
+// "winds steady from SW". Calm days, whose direction is meaningless, are left
+// out; it's "" if fewer than two days have wind.
+func (f *Forecast) WindSummary() string {
+	var windy []DailyForecast
+	for _, day := range f.DailyItems {
+		if day.WindSpeed > 0 {
+			windy = append(windy, day)
+		}
+	}
+	if len(windy) < 2 {
+		return ""
+	}
+
+	points := make([]int, len(windy))
+	counts := make(map[int]int)
+	for i, day := range windy {
+		points[i] = compassIndex(float64(day.WindDirection))
+		counts[points[i]]++
+	}
+	first, last := points[0], points[len(points)-1]
+
+	if counts[first] == len(points) {
+		return "winds steady from " + compassPoints[first]
+	}
+	if first != last {
+		// The shift is dated from when the wind settled into its final
+		// direction.
+		settled := len(points) - 1
+		for settled > 0 && points[settled-1] == last {
+			settled--
+		}
+		return fmt.Sprintf("winds shifting from %s to %s by %s",
+			compassPoints[first], compassPoints[last], windy[settled].Date.Format("Monday"))
+	}
+
+	mostly := first
+	for point, n := range counts {
+		if n > counts[mostly] || n == counts[mostly] && point < mostly {
+			mostly = point
+		}
+	}
+	return "winds variable, mostly from " + compassPoints[mostly]
+}
+
+// beaufortScale gives, for each force on the Beaufort scale, its name and the
+// wind speed in whole mph at which the next force starts.
+var beaufortScale = []struct {
+	below int
+	name  string
+}{
+	{1, "Calm"},
+	{4, "Light air"},
+	{8, "Light breeze"},
+	{13, "Gentle breeze"},
+	{19, "Moderate breeze"},
+	{25, "Fresh breeze"},
+	{32, "Strong breeze"},
+	{39, "Near gale"},
+	{47, "Gale"},
+	{55, "Strong gale"},
+	{64, "Storm"},
+	{73, "Violent storm"},
+	{math.MaxInt, "Hurricane force"},
+}
+
+// Beaufort returns the force on the Beaufort scale, from 0 to 12, of a wind
+// of windSpeedMph, and its name, such as "Moderate breeze". The speed is
+// rounded to the nearest mph first, as the scale's ranges are in whole mph:
+// 13 to 18 mph is force 4.
+func Beaufort(windSpeedMph float64) (int, string) {
+	mph := math.Round(windSpeedMph)
+	for force, f := range beaufortScale {
+		if mph < float64(f.below) {
+			return force, f.name
+		}
+	}
+	last := len(beaufortScale) - 1
+	return last, beaufortScale[last].name
+}
@@ -0,0 +1,44 @@
+package weather
+
+import "testing"
+
+func TestBeaufort(t *testing.T) {
+	tests := []struct {
+		mph   float64
+		force int
+		name  string
+	}{
+		{0, 0, "Calm"},
+		{0.49, 0, "Calm"},
+		{0.5, 1, "Light air"},
+		{3.49, 1, "Light air"},
+		{3.5, 2, "Light breeze"},
+		{7.49, 2, "Light breeze"},
+		{7.5, 3, "Gentle breeze"},
+		{12.49, 3, "Gentle breeze"},
+		{12.5, 4, "Moderate breeze"},
+		{18.49, 4, "Moderate breeze"},
+		{18.5, 5, "Fresh breeze"},
+		{24.49, 5, "Fresh breeze"},
+		{24.5, 6, "Strong breeze"},
+		{31.49, 6, "Strong breeze"},
+		{31.5, 7, "Near gale"},
+		{38.49, 7, "Near gale"},
+		{38.5, 8, "Gale"},
+		{46.49, 8, "Gale"},
+		{46.5, 9, "Strong gale"},
+		{54.49, 9, "Strong gale"},
+		{54.5, 10, "Storm"},
+		{63.49, 10, "Storm"},
+		{63.5, 11, "Violent storm"},
+		{72.49, 11, "Violent storm"},
+		{72.5, 12, "Hurricane force"},
+		{150, 12, "Hurricane force"},
+	}
+	for _, tt := range tests {
+		force, name := Beaufort(tt.mph)
+		if force != tt.force || name != tt.name {
+			t.Errorf("Beaufort(%v) = %d, %q; want %d, %q", tt.mph, force, name, tt.force, tt.name)
+		}
+	}
+}